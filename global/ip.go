@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/snowlyg/helper/arr"
+	"github.com/snowlyg/helper/ipx"
+	"github.com/snowlyg/helper/sys"
 )
 
 func GetMacAddr() string {
@@ -23,6 +25,16 @@ func GetMacAddr() string {
 	return ""
 }
 
+// primaryMAC 主接口的 MAC 地址，测试时替换
+var primaryMAC = sys.PrimaryMAC
+
+// GetMacAddrByPolicy 按规则选择主接口并返回 MAC 地址，格式和 GetMacAddr 一致，例如 00163E000001
+// @param policy 主接口规则,零值时跳过虚拟接口和未启用的接口
+func GetMacAddrByPolicy(policy sys.PrimaryPolicy) string {
+	addr := primaryMAC(policy)
+	return strings.ToUpper(strings.ReplaceAll(addr, ":", ""))
+}
+
 func getMacAddrInterface() *net.Interface {
 	netInterfaces, err := net.Interfaces()
 	if err != nil {
@@ -47,33 +59,57 @@ func getMacAddrInterface() *net.Interface {
 	return nil
 }
 
+// check ip 是否在 network 网段内，ip 和 network 中的地址相同时返回 false
 func check(ip, network string) bool {
-	parseIp, subnet, err := net.ParseCIDR(network)
+	prefix, err := ipx.ParsePrefix(network)
 	if err != nil {
 		return false
 	}
-	if ip == parseIp.String() {
+	addr, err := ipx.ParseAddr(ip)
+	if err != nil || addr == prefix.Addr() {
 		return false
 	}
-	return subnet.Contains(net.ParseIP(ip))
+	return prefix.Contains(addr)
 }
 
 func LocalIP(network string) string {
+	return LocalIPByFamily(network, sys.IPv4)
+}
+
+// LocalIPByFamily 本机地址，优先返回在 network 网段内的地址，没有时返回最后一个地址
+// @param network 网段,例如 10.0.0.0/24 或者 fd00::/64
+// @param family 地址类型,IPDual 时 IPv4 优先
+func LocalIPByFamily(network string, family sys.IPFamily) string {
 	ip := ""
-	if addrs, err := net.InterfaceAddrs(); err == nil {
-		for i, addr := range addrs {
-			i += 1
-			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && !ipnet.IP.IsMulticast() && !ipnet.IP.IsLinkLocalUnicast() && !ipnet.IP.IsLinkLocalMulticast() && ipnet.IP.To4() != nil {
-				ip = ipnet.IP.String()
-				if len(ip) > 0 && check(ip, network) {
-					return ip
-				}
-			}
+	for _, addr := range LocalIPs(family) {
+		ip = addr
+		if check(ip, network) {
+			return ip
 		}
 	}
 	return ip
 }
 
+// LocalIPs 本机全局单播地址和 IPv6 唯一本地地址，不包括回环和链路本地地址
+// @param family 地址类型,IPDual 时 IPv4 在前
+func LocalIPs(family sys.IPFamily) []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var all []net.IP
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && !ipnet.IP.IsMulticast() && !ipnet.IP.IsLinkLocalUnicast() && !ipnet.IP.IsLinkLocalMulticast() {
+			all = append(all, ipnet.IP)
+		}
+	}
+	var ips []string
+	for _, ip := range sys.SortIPs(all, family) {
+		ips = append(ips, ip.String())
+	}
+	return ips
+}
+
 func IsPortInUse(host string, port int64) bool {
 	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprintf("%d", port)), time.Second*1)
 	if err == nil {
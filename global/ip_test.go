@@ -1,8 +1,12 @@
 package global
 
 import (
+	"net"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/snowlyg/helper/sys"
 )
 
 var network = "10.0.0.1/22"
@@ -17,6 +21,25 @@ func TestLocalIP(t *testing.T) {
 	})
 }
 
+func TestLocalIPs(t *testing.T) {
+	t.Run("test get local ips by family", func(t *testing.T) {
+		v4, v6, dual := LocalIPs(sys.IPv4), LocalIPs(sys.IPv6), LocalIPs(sys.IPDual)
+		for _, ip := range v4 {
+			if net.ParseIP(ip).To4() == nil {
+				t.Errorf("LocalIPs(IPv4) get %s", ip)
+			}
+		}
+		for _, ip := range v6 {
+			if parsed := net.ParseIP(ip); parsed.To4() != nil || parsed.IsLinkLocalUnicast() {
+				t.Errorf("LocalIPs(IPv6) get %s", ip)
+			}
+		}
+		if want := append(v4[:len(v4):len(v4)], v6...); !reflect.DeepEqual(dual, want) {
+			t.Errorf("LocalIPs(IPDual) want %v but get %v", want, dual)
+		}
+	})
+}
+
 func TestGetMacAddrs(t *testing.T) {
 	want := "00155DDB2E65"
 	t.Run("test get mac addr", func(t *testing.T) {
@@ -27,6 +50,30 @@ func TestGetMacAddrs(t *testing.T) {
 	})
 }
 
+func TestGetMacAddrByPolicy(t *testing.T) {
+	defer func(f func(sys.PrimaryPolicy) string) { primaryMAC = f }(primaryMAC)
+	t.Run("test get mac addr by policy", func(t *testing.T) {
+		var got sys.PrimaryPolicy
+		primaryMAC = func(policy sys.PrimaryPolicy) string {
+			got = policy
+			return "00:16:3e:0a:bc:01"
+		}
+		policy := sys.PrimaryPolicy{Exclude: []string{`^wl`}, RequireIP: true}
+		if mac := GetMacAddrByPolicy(policy); mac != "00163E0ABC01" {
+			t.Errorf("GetMacAddrByPolicy() want %s but get %s", "00163E0ABC01", mac)
+		}
+		if len(got.Exclude) != 1 || !got.RequireIP {
+			t.Errorf("GetMacAddrByPolicy() policy not passed %+v", got)
+		}
+	})
+	t.Run("test no mac addr", func(t *testing.T) {
+		primaryMAC = func(sys.PrimaryPolicy) string { return "" }
+		if mac := GetMacAddrByPolicy(sys.PrimaryPolicy{}); mac != "" {
+			t.Errorf("GetMacAddrByPolicy() want empty but get %s", mac)
+		}
+	})
+}
+
 func TestGetMacAddrInterface(t *testing.T) {
 	want := "eth0"
 	t.Run("test get mac addr interface", func(t *testing.T) {
@@ -50,6 +97,9 @@ func TestCheck(t *testing.T) {
 			t.Error("ip check is fail")
 			return
 		}
+		if !check("fd00::2", "fd00::1/64") || check("fd01::2", "fd00::1/64") {
+			t.Error("ipv6 check is fail")
+		}
 	})
 }
 
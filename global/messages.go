@@ -0,0 +1,83 @@
+package global
+
+import (
+	"github.com/snowlyg/helper/i18n"
+)
+
+// SSH 和端口错误信息，使用 i18n.SetLang 切换语言，i18n.Localize 翻译包装后的错误
+func init() {
+	i18n.Register(i18n.ZhCN, map[string]string{
+		"ssh.connect_fail":        "SSH 连接失败",
+		"ssh.new_session_fail":    "SSH 新建会话失败",
+		"ssh.run_command_fail":    "SSH 执行命令失败",
+		"ssh.keepalive_timeout":   "SSH 心跳超时",
+		"ssh.dial_timeout":        "SSH 连接超时",
+		"ssh.sudo_auth_fail":      "SSH sudo 密码错误",
+		"ssh.no_auth_method":      "SSH 未配置认证方式",
+		"ssh.parse_private_key":   "SSH 私钥解析失败",
+		"ssh.agent_unavailable":   "SSH agent 不可用",
+		"ssh.passphrase_missing":  "SSH 私钥需要密码",
+		"ssh.socks5_handshake":    "SOCKS5 握手失败",
+		"ssh.socks5_version":      "版本 %d",
+		"ssh.socks5_auth":         "不支持的认证方式",
+		"ssh.socks5_command":      "不支持的命令 %d",
+		"ssh.socks5_addr_type":    "不支持的地址类型 %d",
+		"ssh.service_not_found":   "SSH 服务不存在",
+		"ssh.service_unsupported": "SSH 初始化系统不支持该操作",
+		"ssh.service_name":        "SSH 服务名称错误",
+		"ssh.script_render":       "SSH 脚本渲染失败",
+		"ssh.script_file_name":    "SSH 脚本辅助文件名不合法",
+		"ssh.env_name":            "SSH 环境变量名称错误",
+		"ssh.inventory_host_ip":   "主机清单中的主机 IP 为空",
+		"ssh.transfer_fail":       "SSH 文件传输失败",
+		"ssh.checksum_mismatch":   "SSH 文件校验失败",
+		"ssh.scp_protocol":        "scp 协议错误",
+		"ssh.scp_file_name":       "scp 文件名不合法",
+		"ssh.scp_ack":             "scp 应答错误",
+		"ssh.scp_is_dir":          "%s 是目录",
+		"ssh.scp_file_count":      "本地 %d 个文件, 远程 %d 个文件",
+		"ssh.host_key_unknown":    "SSH 主机密钥未知",
+		"ssh.host_key_mismatch":   "SSH 主机密钥不匹配",
+		"ssh.host_key_revoked":    "SSH 主机密钥已吊销",
+		"ssh.host_key_known":      ", 已知 %s %s (%s:%d)",
+		"port.network":            "端口网络类型错误",
+		"port.unavailable":        "没有可用的端口",
+	})
+	i18n.Register(i18n.EnUS, map[string]string{
+		"ssh.connect_fail":        "SSH connection failed",
+		"ssh.new_session_fail":    "SSH failed to create session",
+		"ssh.run_command_fail":    "SSH command failed",
+		"ssh.keepalive_timeout":   "SSH keepalive timeout",
+		"ssh.dial_timeout":        "SSH connection timeout",
+		"ssh.sudo_auth_fail":      "SSH sudo password incorrect",
+		"ssh.no_auth_method":      "SSH no authentication method configured",
+		"ssh.parse_private_key":   "SSH failed to parse private key",
+		"ssh.agent_unavailable":   "SSH agent unavailable",
+		"ssh.passphrase_missing":  "SSH private key requires a passphrase",
+		"ssh.socks5_handshake":    "SOCKS5 handshake failed",
+		"ssh.socks5_version":      "version %d",
+		"ssh.socks5_auth":         "unsupported authentication method",
+		"ssh.socks5_command":      "unsupported command %d",
+		"ssh.socks5_addr_type":    "unsupported address type %d",
+		"ssh.service_not_found":   "SSH service not found",
+		"ssh.service_unsupported": "SSH init system does not support this operation",
+		"ssh.service_name":        "SSH invalid service name",
+		"ssh.script_render":       "SSH script rendering failed",
+		"ssh.script_file_name":    "invalid SSH script helper file name",
+		"ssh.env_name":            "SSH invalid environment variable name",
+		"ssh.inventory_host_ip":   "inventory host IP is empty",
+		"ssh.transfer_fail":       "SSH file transfer failed",
+		"ssh.checksum_mismatch":   "SSH file checksum mismatch",
+		"ssh.scp_protocol":        "scp protocol error",
+		"ssh.scp_file_name":       "invalid scp file name",
+		"ssh.scp_ack":             "invalid scp ack",
+		"ssh.scp_is_dir":          "%s is a directory",
+		"ssh.scp_file_count":      "%d local files, %d remote files",
+		"ssh.host_key_unknown":    "SSH unknown host key",
+		"ssh.host_key_mismatch":   "SSH host key mismatch",
+		"ssh.host_key_revoked":    "SSH host key revoked",
+		"ssh.host_key_known":      ", known %s %s (%s:%d)",
+		"port.network":            "invalid port network",
+		"port.unavailable":        "no available port",
+	})
+}
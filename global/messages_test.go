@@ -0,0 +1,44 @@
+package global
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+func TestMessages(t *testing.T) {
+	t.Run("测试包装的错误翻译", func(t *testing.T) {
+		err := fmt.Errorf("%w: %s", ErrServiceNotFound, "nginx")
+		if got := i18n.Localize(err, i18n.EnUS); got != "SSH service not found: nginx" {
+			t.Errorf("Localize(en-US) get %s", got)
+		}
+		if got := err.Error(); got != "SSH 服务不存在: nginx" {
+			t.Errorf("Error() get %s", got)
+		}
+	})
+	t.Run("测试连接错误翻译", func(t *testing.T) {
+		err := error(&sshError{kind: ErrConnectFail, err: fmt.Errorf("%w: 10.0.0.1", ErrHostKeyUnknown)})
+		if !errors.Is(err, ErrConnectFail) || !errors.Is(err, ErrHostKeyUnknown) {
+			t.Errorf("errors.Is() want true")
+		}
+		if got := i18n.Localize(err, i18n.EnUS); got != "SSH connection failed: SSH unknown host key: 10.0.0.1" {
+			t.Errorf("Localize(en-US) get %s", got)
+		}
+	})
+	t.Run("测试英文信息完整", func(t *testing.T) {
+		for _, err := range []*i18n.Error{
+			ErrConnectFail, ErrNewSessionFail, ErrRunCommandFail, ErrKeepAliveTimeout, ErrDialTimeout,
+			ErrSudoAuthFail, ErrNoAuthMethod, ErrParsePrivateKey, ErrAgentUnavailable, ErrPassphraseMissing,
+			ErrSocks5Handshake, ErrServiceNotFound, ErrServiceUnsupported, ErrServiceName, ErrScriptRender, ErrScriptFileName,
+			ErrTransferFail, ErrChecksumMismatch, ErrHostKeyUnknown, ErrHostKeyMismatch, ErrHostKeyRevoked,
+			ErrPortNetwork, ErrPortUnavailable, ErrScpProtocol, ErrScpFileName, ErrScpAck, ErrEnvName,
+			ErrInventoryHostIP,
+		} {
+			if !i18n.Default.Has(i18n.ZhCN, err.Key) || !i18n.Default.Has(i18n.EnUS, err.Key) {
+				t.Errorf("message %s is missing", err.Key)
+			}
+		}
+	})
+}
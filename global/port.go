@@ -0,0 +1,293 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var (
+	ErrPortNetwork     = i18n.NewError("port.network")
+	ErrPortUnavailable = i18n.NewError("port.unavailable")
+)
+
+// PortReservation 已绑定的端口，释放前其他程序无法使用
+type PortReservation struct {
+	Network string
+	Host    string
+	mu      sync.Mutex
+	ports   []int
+	holders map[int]io.Closer
+}
+
+// ReservePorts 绑定 n 个连续的空闲端口，使用后调用 Release 释放
+// 可以通过 Listener 或者 PacketConn 取出已绑定的端口直接使用，避免释放后被其他程序占用
+// @param network tcp,tcp4,tcp6,udp,udp4,udp6
+// @param host 绑定的地址,为空时绑定所有地址
+// @param n 端口数量
+func ReservePorts(network, host string, n int) (*PortReservation, error) {
+	if !isTCP(network) && !isUDP(network) {
+		return nil, fmt.Errorf("%w: %s", ErrPortNetwork, network)
+	}
+	if n <= 0 {
+		n = 1
+	}
+	for attempt := 0; attempt < 100; attempt++ {
+		// 由系统分配第一个端口，后面的端口依次尝试
+		first, err := bindPort(network, host, 0)
+		if err != nil {
+			return nil, err
+		}
+		base := boundPort(first)
+		r := &PortReservation{Network: network, Host: host, ports: []int{base}, holders: map[int]io.Closer{base: first}}
+		for port := base + 1; port < base+n; port++ {
+			if port > 65535 {
+				break
+			}
+			holder, err := bindPort(network, host, port)
+			if err != nil {
+				break
+			}
+			r.ports = append(r.ports, port)
+			r.holders[port] = holder
+		}
+		if len(r.ports) == n {
+			return r, nil
+		}
+		r.Release()
+	}
+	return nil, fmt.Errorf("%w: %s %d", ErrPortUnavailable, network, n)
+}
+
+// FreePort 空闲端口，返回前会释放，需要保持绑定时使用 ReservePorts
+// @param network tcp,tcp4,tcp6,udp,udp4,udp6
+func FreePort(network string) (int, error) {
+	ports, err := FreePorts(network, 1)
+	if err != nil {
+		return 0, err
+	}
+	return ports[0], nil
+}
+
+// FreePorts n 个连续的空闲端口，返回前会释放
+// @param network tcp,tcp4,tcp6,udp,udp4,udp6
+func FreePorts(network string, n int) ([]int, error) {
+	r, err := ReservePorts(network, loopbackHost(network), n)
+	if err != nil {
+		return nil, err
+	}
+	ports := r.Ports()
+	return ports, r.Release()
+}
+
+// Ports 绑定的端口
+func (r *PortReservation) Ports() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.ports...)
+}
+
+// Listener 取出 tcp 端口的 Listener，取出后由调用方关闭，不是 tcp 或者已取出时返回 nil
+func (r *PortReservation) Listener(port int) net.Listener {
+	ln, _ := r.take(port).(net.Listener)
+	return ln
+}
+
+// PacketConn 取出 udp 端口的 PacketConn，取出后由调用方关闭，不是 udp 或者已取出时返回 nil
+func (r *PortReservation) PacketConn(port int) net.PacketConn {
+	conn, _ := r.take(port).(net.PacketConn)
+	return conn
+}
+
+// Release 释放所有未取出的端口
+func (r *PortReservation) Release() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for port, holder := range r.holders {
+		if err := holder.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(r.holders, port)
+	}
+	return firstErr
+}
+
+// take 取出端口的绑定
+func (r *PortReservation) take(port int) io.Closer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	holder := r.holders[port]
+	delete(r.holders, port)
+	return holder
+}
+
+// bindPort 绑定端口
+func bindPort(network, host string, port int) (io.Closer, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	if isTCP(network) {
+		return net.Listen(network, addr)
+	}
+	return net.ListenPacket(network, addr)
+}
+
+// boundPort 绑定的端口号
+func boundPort(holder io.Closer) int {
+	switch v := holder.(type) {
+	case net.Listener:
+		return v.Addr().(*net.TCPAddr).Port
+	case net.PacketConn:
+		return v.LocalAddr().(*net.UDPAddr).Port
+	}
+	return 0
+}
+
+// loopbackHost 网络类型对应的回环地址，tcp 和 udp 绑定 localhost
+func loopbackHost(network string) string {
+	switch network {
+	case "tcp4", "udp4":
+		return "127.0.0.1"
+	case "tcp6", "udp6":
+		return "::1"
+	}
+	return "localhost"
+}
+
+func isTCP(network string) bool {
+	return network == "tcp" || network == "tcp4" || network == "tcp6"
+}
+
+func isUDP(network string) bool {
+	return network == "udp" || network == "udp4" || network == "udp6"
+}
+
+// PortState 端口状态
+type PortState string
+
+const (
+	PortOpen     PortState = "open"     //tcp 连接成功或者 udp 收到响应
+	PortClosed   PortState = "closed"   //tcp 被拒绝或者 udp 收到 icmp 端口不可达
+	PortFiltered PortState = "filtered" //超时没有响应,udp 端口也可能是打开的
+)
+
+// PortResult 端口扫描结果
+type PortResult struct {
+	Port    int
+	State   PortState
+	Latency time.Duration
+	Err     error //filtered 时的错误
+}
+
+// ScanOptions 端口扫描配置
+type ScanOptions struct {
+	Network     string        //tcp 或者 udp,默认 tcp
+	Timeout     time.Duration //每个端口的超时时间,默认1秒
+	Concurrency int           //并发数,默认100
+	Payload     []byte        //udp 发送的数据,默认为一个换行
+}
+
+// ScanPorts 并发扫描主机端口，结果按端口排序
+// @param host IP 或者域名
+// @param ports 端口,可以使用 PortRange 生成
+// @param opts 配置
+func ScanPorts(ctx context.Context, host string, ports []int, opts ScanOptions) ([]PortResult, error) {
+	if opts.Network == "" {
+		opts.Network = "tcp"
+	}
+	if !isTCP(opts.Network) && !isUDP(opts.Network) {
+		return nil, fmt.Errorf("%w: %s", ErrPortNetwork, opts.Network)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 100
+	}
+	if len(opts.Payload) == 0 {
+		opts.Payload = []byte("\n")
+	}
+	results := make([]PortResult, len(ports))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, port := range ports {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i, port int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = scanPort(ctx, host, port, opts)
+		}(i, port)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool { return results[i].Port < results[j].Port })
+	return results, ctx.Err()
+}
+
+// PortRange from 到 to 的端口，包括 to
+func PortRange(from, to int) []int {
+	if from < 1 {
+		from = 1
+	}
+	if to > 65535 {
+		to = 65535
+	}
+	var ports []int
+	for port := from; port <= to; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// scanPort 扫描一个端口
+func scanPort(ctx context.Context, host string, port int, opts ScanOptions) PortResult {
+	result := PortResult{Port: port}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, opts.Network, net.JoinHostPort(host, strconv.Itoa(port)))
+	if err == nil && isUDP(opts.Network) {
+		err = udpExchange(ctx, conn, opts.Payload)
+	}
+	result.Latency = time.Since(start)
+	if conn != nil {
+		conn.Close()
+	}
+	result.State, result.Err = portState(err)
+	return result
+}
+
+// udpExchange 发送数据并等待响应
+func udpExchange(ctx context.Context, conn net.Conn, payload []byte) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(payload); err != nil {
+		return err
+	}
+	buf := make([]byte, 1500)
+	_, err := conn.Read(buf)
+	return err
+}
+
+// portState 根据错误判断端口状态
+func portState(err error) (PortState, error) {
+	switch {
+	case err == nil:
+		return PortOpen, nil
+	case errors.Is(err, syscall.ECONNREFUSED), strings.Contains(err.Error(), "refused"):
+		// windows 的错误码不是 ECONNREFUSED
+		return PortClosed, nil
+	}
+	return PortFiltered, err
+}
@@ -0,0 +1,159 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReservePorts(t *testing.T) {
+	for _, network := range []string{"tcp", "udp"} {
+		t.Run("测试绑定连续端口:"+network, func(t *testing.T) {
+			r, err := ReservePorts(network, "127.0.0.1", 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Release()
+			ports := r.Ports()
+			if len(ports) != 3 || ports[1] != ports[0]+1 || ports[2] != ports[0]+2 {
+				t.Fatalf("Ports() get %v", ports)
+			}
+			for _, port := range ports {
+				if _, err := bindPort(network, "127.0.0.1", port); err == nil {
+					t.Errorf("port %d is not reserved", port)
+				}
+			}
+		})
+	}
+	t.Run("测试取出端口", func(t *testing.T) {
+		r, err := ReservePorts("tcp", "127.0.0.1", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := r.Ports()[0]
+		ln := r.Listener(port)
+		if ln == nil || ln.Addr().(*net.TCPAddr).Port != port {
+			t.Fatalf("Listener() get %v", ln)
+		}
+		defer ln.Close()
+		if r.Listener(port) != nil || r.PacketConn(port) != nil {
+			t.Error("Listener() want nil after taken")
+		}
+		r.Release()
+		if _, err := bindPort("tcp", "127.0.0.1", port); err == nil {
+			t.Error("taken port is released")
+		}
+	})
+	t.Run("测试网络类型错误", func(t *testing.T) {
+		if _, err := ReservePorts("ip", "", 1); !errors.Is(err, ErrPortNetwork) {
+			t.Errorf("ReservePorts() want %v but get %v", ErrPortNetwork, err)
+		}
+	})
+}
+
+func TestFreePort(t *testing.T) {
+	hasIPv6 := false
+	if ln, err := net.Listen("tcp6", "[::1]:0"); err == nil {
+		hasIPv6 = true
+		ln.Close()
+	}
+	tests := []struct {
+		network string
+		host    string
+		ipv6    bool
+	}{
+		{network: "tcp", host: "localhost"},
+		{network: "tcp4", host: "127.0.0.1"},
+		{network: "tcp6", host: "::1", ipv6: true},
+		{network: "udp", host: "localhost"},
+		{network: "udp4", host: "127.0.0.1"},
+		{network: "udp6", host: "::1", ipv6: true},
+	}
+	for _, tt := range tests {
+		t.Run("测试空闲端口:"+tt.network, func(t *testing.T) {
+			if tt.ipv6 && !hasIPv6 {
+				t.Skip("没有 IPv6")
+			}
+			port, err := FreePort(tt.network)
+			if err != nil {
+				t.Fatal(err)
+			}
+			holder, err := bindPort(tt.network, tt.host, port)
+			if err != nil {
+				t.Fatal(err)
+			}
+			holder.Close()
+		})
+	}
+}
+
+func TestScanPorts(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			udp.WriteTo(buf[:n], addr)
+		}
+	}()
+	closed, err := FreePorts("tcp", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedUDP, err := FreePorts("udp", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcpPort := ln.Addr().(*net.TCPAddr).Port
+	udpPort := udp.LocalAddr().(*net.UDPAddr).Port
+
+	t.Run("测试扫描 tcp 端口", func(t *testing.T) {
+		results, err := ScanPorts(context.Background(), "127.0.0.1", []int{tcpPort, closed[0]}, ScanOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[int]PortState{tcpPort: PortOpen, closed[0]: PortClosed}
+		for _, r := range results {
+			if r.State != want[r.Port] {
+				t.Errorf("ScanPorts() port %d want %s but get %s %v", r.Port, want[r.Port], r.State, r.Err)
+			}
+		}
+	})
+	t.Run("测试扫描 udp 端口", func(t *testing.T) {
+		results, err := ScanPorts(context.Background(), "127.0.0.1", []int{udpPort, closedUDP[0]}, ScanOptions{Network: "udp", Timeout: 500 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[int]PortState{udpPort: PortOpen, closedUDP[0]: PortClosed}
+		for _, r := range results {
+			if r.State != want[r.Port] {
+				t.Errorf("ScanPorts() port %d want %s but get %s %v", r.Port, want[r.Port], r.State, r.Err)
+			}
+		}
+	})
+	t.Run("测试端口范围", func(t *testing.T) {
+		if ports := PortRange(65534, 70000); len(ports) != 2 || ports[1] != 65535 {
+			t.Errorf("PortRange() get %v", ports)
+		}
+	})
+	t.Run("测试超时", func(t *testing.T) {
+		state, err := portState(context.DeadlineExceeded)
+		if state != PortFiltered || err == nil {
+			t.Errorf("portState() get %s %v", state, err)
+		}
+	})
+}
@@ -1,34 +1,70 @@
 package global
 
 import (
-	"errors"
 	"fmt"
 	"net"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"log"
 
 	"github.com/shopspring/decimal"
+	"github.com/snowlyg/helper/i18n"
 	"golang.org/x/crypto/ssh"
 )
 
 var (
-	ErrConnectFail    = errors.New("SSH 连接失败")
-	ErrNewSessionFail = errors.New("SSH 新建会话失败")
-	ErrRunCommandFail = errors.New("SSH 执行命令失败")
+	ErrConnectFail    = i18n.NewError("ssh.connect_fail")
+	ErrNewSessionFail = i18n.NewError("ssh.new_session_fail")
+	ErrRunCommandFail = i18n.NewError("ssh.run_command_fail")
+
+	ErrKeepAliveTimeout = i18n.NewError("ssh.keepalive_timeout")
+	ErrDialTimeout      = i18n.NewError("ssh.dial_timeout")
 )
 
+// sshError 保留底层错误，同时兼容 ErrConnectFail 等错误判断
+type sshError struct {
+	kind error
+	err  error
+}
+
+func (e *sshError) Error() string {
+	return fmt.Sprintf("%s: %s", e.kind.Error(), e.err.Error())
+}
+
+// Localize 翻译错误类型，底层错误不变
+func (e *sshError) Localize(lang i18n.Lang) string {
+	return i18n.Localize(e.kind, lang) + ": " + i18n.Localize(e.err, lang)
+}
+
+func (e *sshError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *sshError) Unwrap() error {
+	return e.err
+}
+
 type Cli struct {
-	IP         string      //IP地址
-	Username   string      //用户名
-	Password   string      //密码
-	Port       int         //端口号
-	client     *ssh.Client //ssh客户端
-	LastResult string      //最近一次Run的结果
+	IP         string        //IP地址
+	Username   string        //用户名
+	Password   string        //密码
+	Port       int           //端口号
+	KeepAlive  time.Duration //心跳间隔,默认30秒,小于等于0时不发送心跳
+	client     *ssh.Client   //ssh客户端,多次执行命令复用同一个连接
+	LastResult string        //最近一次Run的结果
 	Debug      bool
+	mu         sync.Mutex
+	auths      []authMethod                        //认证方式,为空时使用密码认证
+	signers    []ssh.Signer                        //私钥认证的私钥
+	useAgent   bool                                //是否使用 ssh-agent 中的私钥
+	hostKey    func() (ssh.HostKeyCallback, error) //主机密钥校验,为空时不校验
+	optErr     error                               //配置项错误
+	jumps      []*Cli                              //跳板机,按顺序连接,为空时直接连接
+	initSystem InitSystem                          //远程主机的初始化系统,第一次使用时检测
 }
 
 // 创建命令行对象
@@ -43,6 +79,7 @@ func NewSSH(ip string, username string, password string, debug bool, port ...int
 	cli.Username = username
 	cli.Password = password
 	cli.Debug = debug
+	cli.KeepAlive = 30 * time.Second
 	if len(port) <= 0 {
 		cli.Port = 22
 	} else {
@@ -52,57 +89,73 @@ func NewSSH(ip string, username string, password string, debug bool, port ...int
 }
 
 // 执行shell
+// 命令执行失败时返回已有的输出和 *ExitError
 // @param shell shell脚本命令
-func (c Cli) Run(shell string) (string, error) {
-	if c.client == nil {
-		if err := c.connect(); err != nil {
-			if c.Debug {
-				log.Println(err.Error())
-			}
-			return "", ErrConnectFail
-		}
+func (c *Cli) Run(shell string) (string, error) {
+	var buf lockedBuffer
+	_, err := c.exec(shell, nil, &buf, &buf)
+	if err != nil {
+		return buf.String(), err
 	}
-	defer c.client.Close()
-	session, err := c.client.NewSession()
+
+	c.mu.Lock()
+	c.LastResult = buf.String()
+	c.mu.Unlock()
+	return buf.String(), nil
+}
+
+// 连接
+func (c *Cli) connect() error {
+	sshClient, err := c.handshake(c.dial)
+	if err != nil {
+		return err
+	}
+	c.client = sshClient
+	go c.keepAlive(sshClient)
+	return nil
+}
+
+// handshake 使用 Cli 的认证方式和主机密钥校验建立 ssh 连接
+// @param dial 建立连接,直接连接或者通过跳板机转发
+func (c *Cli) handshake(dial func(addr string, config *ssh.ClientConfig) (*ssh.Client, error)) (*ssh.Client, error) {
+	auths, cleanup, err := c.authMethods()
 	if err != nil {
 		if c.Debug {
 			log.Println(err.Error())
 		}
-		return "", ErrNewSessionFail
+		return nil, err
 	}
-	defer session.Close()
-	buf, err := session.CombinedOutput(shell)
+	defer cleanup()
+	hostKeyCallback, err := c.hostKeyCallback()
 	if err != nil {
 		if c.Debug {
 			log.Println(err.Error())
 		}
-		return "", ErrRunCommandFail
+		return nil, err
 	}
-
-	c.LastResult = string(buf)
-	return c.LastResult, nil
-}
-
-// 连接
-func (c *Cli) connect() error {
+	// ssh 握手错误不会保留主机密钥校验的错误类型，这里单独记录
+	var hostKeyErr error
 	config := ssh.ClientConfig{
 		User: c.Username,
-		Auth: []ssh.AuthMethod{ssh.Password(c.Password)},
+		Auth: auths,
 		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			return nil
+			hostKeyErr = hostKeyCallback(hostname, remote, key)
+			return hostKeyErr
 		},
 		Timeout: 10 * time.Second,
 	}
-	addr := fmt.Sprintf("%s:%d", c.IP, c.Port)
-	sshClient, err := ssh.Dial("tcp", addr, &config)
+	addr := net.JoinHostPort(c.IP, strconv.Itoa(c.Port))
+	sshClient, err := dial(addr, &config)
 	if err != nil {
 		if c.Debug {
 			log.Println(err.Error())
 		}
-		return err
+		if hostKeyErr != nil {
+			return nil, hostKeyErr
+		}
+		return nil, err
 	}
-	c.client = sshClient
-	return nil
+	return sshClient, nil
 }
 
 type DeviceMem struct {
@@ -111,46 +164,24 @@ type DeviceMem struct {
 	FreeRound decimal.Decimal
 }
 
-// GetMem 内存信息
+// GetMem 内存信息，单位 kB
 func (c *Cli) GetMem() (DeviceMem, error) {
 	var deviceMen DeviceMem
-	totalDec, err := c.getMenInfo("cat /proc/meminfo | grep -w MemTotal", "MemTotal:")
+	info, err := c.Run("cat /proc/meminfo")
 	if err != nil {
-		return deviceMen, fmt.Errorf("memTotal %w", err)
+		return deviceMen, fmt.Errorf("cat /proc/meminfo %w", err)
 	}
-	freeDec, err := c.getMenInfo("cat /proc/meminfo | grep -w MemFree", "MemFree:")
+	mem, err := parseMeminfo(info)
 	if err != nil {
-		return deviceMen, fmt.Errorf("memFree %w", err)
-	}
-	buffersDec, err := c.getMenInfo("cat /proc/meminfo | grep -w Buffers", "Buffers:")
-	if err != nil {
-		return deviceMen, fmt.Errorf("buffers %w", err)
-	}
-	cachedDec, err := c.getMenInfo("cat /proc/meminfo | grep -w Cached", "Cached:")
-	if err != nil {
-		return deviceMen, fmt.Errorf("cached %w", err)
+		return deviceMen, err
 	}
 
-	deviceMen.Total = totalDec
-	deviceMen.Free = freeDec.Add(buffersDec).Add(cachedDec)
+	deviceMen.Total = decimal.NewFromInt(int64(mem.Total / 1024))
+	deviceMen.Free = decimal.NewFromInt(int64((mem.Free + mem.Buffers + mem.Cached) / 1024))
 	deviceMen.FreeRound = deviceMen.Free.DivRound(deviceMen.Total, 2)
 	return deviceMen, nil
 }
 
-// getMenInfo 内存信息
-func (c *Cli) getMenInfo(cmd, key string) (decimal.Decimal, error) {
-	info, err := c.Run(cmd)
-	if err != nil {
-		return decimal.Zero, fmt.Errorf("%s %w", cmd, err)
-	}
-
-	dec, err := decimal.NewFromString(strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(info, key, ""), "kB", "")))
-	if err != nil {
-		return decimal.Zero, fmt.Errorf("NewFromString %w", err)
-	}
-	return dec, err
-}
-
 // GetDf 硬盘率
 func (c *Cli) GetDf() (string, error) {
 	total, err := c.Run("df /sdcard -h")
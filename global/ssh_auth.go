@@ -0,0 +1,223 @@
+package global
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"github.com/snowlyg/helper/i18n"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	ErrNoAuthMethod      = i18n.NewError("ssh.no_auth_method")
+	ErrParsePrivateKey   = i18n.NewError("ssh.parse_private_key")
+	ErrAgentUnavailable  = i18n.NewError("ssh.agent_unavailable")
+	ErrPassphraseMissing = i18n.NewError("ssh.passphrase_missing")
+)
+
+// SSHOption 命令行对象配置项
+type SSHOption func(*Cli)
+
+// 认证方式，连接时才会生成 ssh.AuthMethod
+type authMethod func(c *Cli) (ssh.AuthMethod, func(), error)
+
+// NewSSHClient 通过配置项创建命令行对象
+// @param ip IP地址
+// @param username 用户名
+// @param opts 配置项，未配置认证方式时使用密码认证
+func NewSSHClient(ip string, username string, opts ...SSHOption) *Cli {
+	cli := NewSSH(ip, username, "", false)
+	for _, opt := range opts {
+		opt(cli)
+	}
+	return cli
+}
+
+// WithPort 端口号
+func WithPort(port int) SSHOption {
+	return func(c *Cli) {
+		c.Port = port
+	}
+}
+
+// WithDebug 是否调试
+func WithDebug(debug bool) SSHOption {
+	return func(c *Cli) {
+		c.Debug = debug
+	}
+}
+
+// WithPassword 密码认证
+func WithPassword(password string) SSHOption {
+	return func(c *Cli) {
+		c.Password = password
+		c.auths = append(c.auths, func(c *Cli) (ssh.AuthMethod, func(), error) {
+			return ssh.Password(c.Password), nil, nil
+		})
+	}
+}
+
+// WithPrivateKey 私钥认证，支持 PEM 和 OpenSSH 格式
+// 多个私钥和 ssh-agent 合并为一个 publickey 认证方式，按配置顺序尝试
+// @param pemBytes 私钥内容
+// @param passphrase 私钥密码，可选
+func WithPrivateKey(pemBytes []byte, passphrase ...string) SSHOption {
+	return func(c *Cli) {
+		signer, err := parsePrivateKey(pemBytes, passphrase...)
+		if err != nil {
+			c.optErr = err
+			return
+		}
+		c.addPublicKey()
+		c.signers = append(c.signers, signer)
+	}
+}
+
+// WithPrivateKeyFile 私钥文件认证
+// @param path 私钥文件路径
+// @param passphrase 私钥密码，可选
+func WithPrivateKeyFile(path string, passphrase ...string) SSHOption {
+	return func(c *Cli) {
+		pemBytes, err := os.ReadFile(path)
+		if err != nil {
+			c.optErr = fmt.Errorf("%w: %s %v", ErrParsePrivateKey, path, err)
+			return
+		}
+		WithPrivateKey(pemBytes, passphrase...)(c)
+	}
+}
+
+// WithAgent ssh-agent 认证，通过 SSH_AUTH_SOCK 连接 agent，agent 中的私钥在配置的私钥之后尝试
+func WithAgent() SSHOption {
+	return func(c *Cli) {
+		c.addPublicKey()
+		c.useAgent = true
+	}
+}
+
+// addPublicKey 添加 publickey 认证方式，ssh 每种认证方式只尝试一次，所以私钥和 agent 只添加一次
+func (c *Cli) addPublicKey() {
+	if len(c.signers) > 0 || c.useAgent {
+		return
+	}
+	c.auths = append(c.auths, publicKeyAuth)
+}
+
+// publicKeyAuth 使用所有私钥和 agent 中的私钥认证，agent 不可用但是有私钥时只使用私钥
+func publicKeyAuth(c *Cli) (ssh.AuthMethod, func(), error) {
+	signers := append([]ssh.Signer(nil), c.signers...)
+	if !c.useAgent {
+		return ssh.PublicKeys(signers...), nil, nil
+	}
+	conn, err := dialAgent()
+	if err != nil {
+		if len(signers) == 0 {
+			return nil, nil, err
+		}
+		if c.Debug {
+			log.Println(err.Error())
+		}
+		return ssh.PublicKeys(signers...), nil, nil
+	}
+	client := agent.NewClient(conn)
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		agentSigners, err := client.Signers()
+		if err != nil {
+			if len(signers) == 0 {
+				return nil, err
+			}
+			return signers, nil
+		}
+		return append(signers, agentSigners...), nil
+	}), func() { conn.Close() }, nil
+}
+
+// dialAgent 连接 SSH_AUTH_SOCK
+func dialAgent() (net.Conn, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("%w: SSH_AUTH_SOCK 为空", ErrAgentUnavailable)
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAgentUnavailable, err)
+	}
+	return conn, nil
+}
+
+// WithKeyboardInteractive keyboard-interactive 认证
+// @param challenge 问题回答函数，为 nil 时使用密码回答所有问题
+func WithKeyboardInteractive(challenge ssh.KeyboardInteractiveChallenge) SSHOption {
+	return func(c *Cli) {
+		c.auths = append(c.auths, func(c *Cli) (ssh.AuthMethod, func(), error) {
+			if challenge != nil {
+				return ssh.KeyboardInteractive(challenge), nil, nil
+			}
+			return ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = c.Password
+				}
+				return answers, nil
+			}), nil, nil
+		})
+	}
+}
+
+// parsePrivateKey 解析私钥
+func parsePrivateKey(pemBytes []byte, passphrase ...string) (ssh.Signer, error) {
+	var signer ssh.Signer
+	var err error
+	if len(passphrase) > 0 && passphrase[0] != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase[0]))
+	} else {
+		signer, err = ssh.ParsePrivateKey(pemBytes)
+	}
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, ErrPassphraseMissing
+		}
+		return nil, fmt.Errorf("%w: %v", ErrParsePrivateKey, err)
+	}
+	return signer, nil
+}
+
+// authMethods 生成认证方式，返回的清理函数需要在握手完成后调用
+func (c *Cli) authMethods() ([]ssh.AuthMethod, func(), error) {
+	if c.optErr != nil {
+		return nil, nil, c.optErr
+	}
+	if len(c.auths) == 0 {
+		return []ssh.AuthMethod{ssh.Password(c.Password)}, func() {}, nil
+	}
+	var methods []ssh.AuthMethod
+	var cleanups []func()
+	cleanup := func() {
+		for _, f := range cleanups {
+			f()
+		}
+	}
+	for _, auth := range c.auths {
+		method, clean, err := auth(c)
+		if err != nil {
+			if c.Debug {
+				log.Println(err.Error())
+			}
+			continue
+		}
+		if clean != nil {
+			cleanups = append(cleanups, clean)
+		}
+		methods = append(methods, method)
+	}
+	if len(methods) == 0 {
+		cleanup()
+		return nil, nil, ErrNoAuthMethod
+	}
+	return methods, cleanup, nil
+}
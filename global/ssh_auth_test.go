@@ -0,0 +1,167 @@
+package global
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/snowlyg/helper/global/sshtest"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestRsaPem(t *testing.T, passphrase string) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if passphrase != "" {
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pem.EncodeToMemory(block)
+}
+
+func TestNewSSHClient(t *testing.T) {
+	t.Run("测试默认配置", func(t *testing.T) {
+		cli := NewSSHClient("127.0.0.1", "root")
+		if cli.Port != 22 {
+			t.Errorf("NewSSHClient() default port want %d but get %d", 22, cli.Port)
+		}
+		auths, _, err := cli.authMethods()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(auths) != 1 {
+			t.Errorf("NewSSHClient() default auth methods want %d but get %d", 1, len(auths))
+		}
+	})
+	t.Run("测试配置项", func(t *testing.T) {
+		cli := NewSSHClient("127.0.0.1", "root", WithPort(2022), WithDebug(true), WithPassword("pwd"), WithKeyboardInteractive(nil))
+		if cli.Port != 2022 || !cli.Debug || cli.Password != "pwd" {
+			t.Errorf("NewSSHClient() options not applied %+v", cli)
+		}
+		auths, _, err := cli.authMethods()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(auths) != 2 {
+			t.Errorf("NewSSHClient() auth methods want %d but get %d", 2, len(auths))
+		}
+	})
+}
+
+func TestWithPrivateKey(t *testing.T) {
+	t.Run("测试私钥认证", func(t *testing.T) {
+		cli := NewSSHClient("127.0.0.1", "root", WithPrivateKey(newTestRsaPem(t, "")))
+		if _, _, err := cli.authMethods(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("测试带密码私钥认证", func(t *testing.T) {
+		key := newTestRsaPem(t, "secret")
+		cli := NewSSHClient("127.0.0.1", "root", WithPrivateKey(key, "secret"))
+		if _, _, err := cli.authMethods(); err != nil {
+			t.Error(err)
+		}
+		cli = NewSSHClient("127.0.0.1", "root", WithPrivateKey(key))
+		if _, _, err := cli.authMethods(); !errors.Is(err, ErrPassphraseMissing) {
+			t.Errorf("authMethods() want %v but get %v", ErrPassphraseMissing, err)
+		}
+	})
+	t.Run("测试错误私钥", func(t *testing.T) {
+		cli := NewSSHClient("127.0.0.1", "root", WithPrivateKey([]byte("not a key")))
+		if _, _, err := cli.authMethods(); !errors.Is(err, ErrParsePrivateKey) {
+			t.Errorf("authMethods() want %v but get %v", ErrParsePrivateKey, err)
+		}
+		cli = NewSSHClient("127.0.0.1", "root", WithPrivateKeyFile("./not_exist_key"))
+		if _, _, err := cli.authMethods(); !errors.Is(err, ErrParsePrivateKey) {
+			t.Errorf("authMethods() want %v but get %v", ErrParsePrivateKey, err)
+		}
+	})
+}
+
+func TestWithAgent(t *testing.T) {
+	t.Run("测试 agent 不可用", func(t *testing.T) {
+		t.Setenv("SSH_AUTH_SOCK", "")
+		cli := NewSSHClient("127.0.0.1", "root", WithAgent())
+		if _, _, err := cli.authMethods(); !errors.Is(err, ErrNoAuthMethod) {
+			t.Errorf("authMethods() want %v but get %v", ErrNoAuthMethod, err)
+		}
+	})
+}
+
+func TestAuthServer(t *testing.T) {
+	srv := sshtest.NewServer(sshtest.Output("ok", "", 0))
+	defer srv.Close()
+	key := newTestRsaPem(t, "secret")
+	signer, err := ssh.ParsePrivateKeyWithPassphrase(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AddKey("deploy", signer.PublicKey())
+	tests := []struct {
+		name    string
+		user    string
+		opts    []SSHOption
+		wantErr bool
+	}{
+		{name: "私钥认证", user: "deploy", opts: []SSHOption{WithPrivateKey(key, "secret")}},
+		{name: "私钥未授权", user: sshtest.DefaultUser, opts: []SSHOption{WithPrivateKey(key, "secret")}, wantErr: true},
+		{name: "多个私钥只授权第二个", user: "deploy", opts: []SSHOption{WithPrivateKey(newTestRsaPem(t, "")), WithPrivateKey(key, "secret")}},
+		{name: "密码错误", user: sshtest.DefaultUser, opts: []SSHOption{WithPassword("wrong")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run("测试"+tt.name, func(t *testing.T) {
+			cli := NewSSHClient(srv.Host(), tt.user, append([]SSHOption{WithPort(srv.Port())}, tt.opts...)...)
+			defer cli.Close()
+			_, err := cli.Run("true")
+			if tt.wantErr != (err != nil) {
+				t.Errorf("Run() wantErr %v but get %v", tt.wantErr, err)
+			}
+			if tt.wantErr && !errors.Is(err, ErrConnectFail) {
+				t.Errorf("Run() want ErrConnectFail but get %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyboardInteractiveServer(t *testing.T) {
+	// 只支持键盘交互认证的服务端
+	srv := sshtest.NewUnstartedServer(sshtest.Output("ok", "", 0))
+	srv.Config = func(config *ssh.ServerConfig) {
+		config.PasswordCallback = nil
+		config.PublicKeyCallback = nil
+	}
+	srv.Start()
+	defer srv.Close()
+	t.Run("测试键盘交互认证", func(t *testing.T) {
+		cli := NewSSHClient(srv.Host(), sshtest.DefaultUser, WithPort(srv.Port()), WithPassword(sshtest.DefaultPassword), WithKeyboardInteractive(nil))
+		defer cli.Close()
+		if _, err := cli.Run("true"); err != nil {
+			t.Errorf("Run() get %v", err)
+		}
+	})
+	t.Run("测试自定义键盘交互回答", func(t *testing.T) {
+		challenge := func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+			return []string{sshtest.DefaultPassword}, nil
+		}
+		cli := NewSSHClient(srv.Host(), sshtest.DefaultUser, WithPort(srv.Port()), WithKeyboardInteractive(challenge))
+		defer cli.Close()
+		if _, err := cli.Run("true"); err != nil {
+			t.Errorf("Run() get %v", err)
+		}
+	})
+	t.Run("测试只有密码认证", func(t *testing.T) {
+		cli := NewSSHClient(srv.Host(), sshtest.DefaultUser, WithPort(srv.Port()), WithPassword(sshtest.DefaultPassword))
+		defer cli.Close()
+		if _, err := cli.Run("true"); !errors.Is(err, ErrConnectFail) {
+			t.Errorf("Run() want ErrConnectFail but get %v", err)
+		}
+	})
+}
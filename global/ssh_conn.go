@@ -0,0 +1,132 @@
+package global
+
+import (
+	"log"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// WithKeepAlive 心跳间隔，小于等于0时不发送心跳
+func WithKeepAlive(interval time.Duration) SSHOption {
+	return func(c *Cli) {
+		c.KeepAlive = interval
+	}
+}
+
+// Client 获取 ssh 客户端，未连接或者连接已断开时重新连接
+func (c *Cli) Client() (*ssh.Client, error) {
+	client, _, err := c.clientConnected()
+	return client, err
+}
+
+// clientConnected 获取 ssh 客户端，connected 表示本次调用新建了连接
+func (c *Cli) clientConnected() (client *ssh.Client, connected bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		if err := c.connect(); err != nil {
+			return nil, false, &sshError{kind: ErrConnectFail, err: err}
+		}
+		connected = true
+	}
+	return c.client, connected, nil
+}
+
+// Close 关闭连接，之后再执行命令会重新连接
+func (c *Cli) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Close()
+	c.client = nil
+	return err
+}
+
+// newSession 在已有连接上新建会话，连接失效时重新连接一次
+func (c *Cli) newSession() (*ssh.Session, error) {
+	session, _, _, err := c.newSessionClient()
+	return session, err
+}
+
+// newSessionClient 新建会话，同时返回使用的连接以及连接是否是本次新建的
+func (c *Cli) newSessionClient() (*ssh.Session, *ssh.Client, bool, error) {
+	client, connected, err := c.clientConnected()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	session, err := client.NewSession()
+	if err == nil {
+		return session, client, connected, nil
+	}
+	if c.Debug {
+		log.Println(err.Error())
+	}
+	c.resetClient(client)
+	client, connected, err = c.clientConnected()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	session, err = client.NewSession()
+	if err != nil {
+		if c.Debug {
+			log.Println(err.Error())
+		}
+		return nil, client, connected, &sshError{kind: ErrNewSessionFail, err: err}
+	}
+	return session, client, connected, nil
+}
+
+// resetClient 关闭失效的连接，下次使用时重新连接
+func (c *Cli) resetClient(client *ssh.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client == client {
+		c.client.Close()
+		c.client = nil
+	}
+}
+
+// keepAlive 定时发送心跳，心跳超时或者连接断开时重置连接
+func (c *Cli) keepAlive(client *ssh.Client) {
+	done := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(done)
+		c.resetClient(client)
+	}()
+	if c.KeepAlive <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			reply := make(chan error, 1)
+			go func() {
+				_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+				reply <- err
+			}()
+			var err error
+			select {
+			case err = <-reply:
+			case <-time.After(c.KeepAlive):
+				err = ErrKeepAliveTimeout
+			case <-done:
+				return
+			}
+			if err != nil {
+				if c.Debug {
+					log.Println(err.Error())
+				}
+				c.resetClient(client)
+				return
+			}
+		}
+	}
+}
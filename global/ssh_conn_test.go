@@ -0,0 +1,35 @@
+package global
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCliClose(t *testing.T) {
+	t.Run("测试关闭未连接的客户端", func(t *testing.T) {
+		cli := NewSSHClient("127.0.0.1", "root", WithKeepAlive(time.Second))
+		if cli.KeepAlive != time.Second {
+			t.Errorf("WithKeepAlive() want %v but get %v", time.Second, cli.KeepAlive)
+		}
+		if err := cli.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("测试连接失败", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+		cli := NewSSHClient("127.0.0.1", "root", WithPort(port), WithPassword("pwd"))
+		if _, err := cli.Run("uptime"); !errors.Is(err, ErrConnectFail) {
+			t.Errorf("Run() want %v but get %v", ErrConnectFail, err)
+		}
+		if cli.client != nil {
+			t.Error("client should be nil after connect fail")
+		}
+	})
+}
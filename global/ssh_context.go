@@ -0,0 +1,208 @@
+package global
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snowlyg/helper/i18n"
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrEnvName = i18n.NewError("ssh.env_name")
+
+// envNameRe 环境变量名称，服务端拒绝设置时名称会拼接到 export 命令中
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// killGrace 取消命令时发送信号后等待退出的时间，超时后关闭会话
+const killGrace = time.Second
+
+// RunOption 命令执行配置项
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	env    map[string]string
+	dir    string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// WithEnv 设置环境变量，名称只能包含字母、数字和下划线，并且不能以数字开头
+// 服务端拒绝设置时(OpenSSH 只接受 AcceptEnv 配置的变量)改为在命令前 export
+func WithEnv(key, value string) RunOption {
+	return func(cfg *runConfig) {
+		if cfg.env == nil {
+			cfg.env = map[string]string{}
+		}
+		cfg.env[key] = value
+	}
+}
+
+// WithDir 工作目录
+func WithDir(dir string) RunOption {
+	return func(cfg *runConfig) {
+		cfg.dir = dir
+	}
+}
+
+// WithStdin 标准输入
+func WithStdin(stdin io.Reader) RunOption {
+	return func(cfg *runConfig) {
+		cfg.stdin = stdin
+	}
+}
+
+// WithOutput 输出同时实时写入 stdout 和 stderr，可以为 nil
+func WithOutput(stdout, stderr io.Writer) RunOption {
+	return func(cfg *runConfig) {
+		cfg.stdout = stdout
+		cfg.stderr = stderr
+	}
+}
+
+// RunContext 执行命令，ctx 取消或者超时时先发送 TERM 信号，1秒后关闭会话
+// 返回结果包含标准输出和标准错误
+// @param cmd shell脚本命令
+func (c *Cli) RunContext(ctx context.Context, cmd string, opts ...RunOption) (*RunResult, error) {
+	cfg := &runConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	for k := range cfg.env {
+		if !envNameRe.MatchString(k) {
+			return nil, fmt.Errorf("%w: %q", ErrEnvName, k)
+		}
+	}
+	// ctx 取消后不再等待会话，输出可能仍在写入
+	var stdout, stderr lockedBuffer
+	out, errOut := io.Writer(&stdout), io.Writer(&stderr)
+	if cfg.stdout != nil {
+		out = io.MultiWriter(&stdout, cfg.stdout)
+	}
+	if cfg.stderr != nil {
+		errOut = io.MultiWriter(&stderr, cfg.stderr)
+	}
+	cfg.stdout, cfg.stderr = out, errOut
+	result, err := c.runContext(ctx, cmd, cfg)
+	if result != nil {
+		result.Stdout = stdout.String()
+		result.Stderr = stderr.String()
+	}
+	return result, err
+}
+
+// runContext 新建会话执行命令
+func (c *Cli) runContext(ctx context.Context, cmd string, cfg *runConfig) (*RunResult, error) {
+	session, err := c.newSessionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	session.Stdin = cfg.stdin
+	session.Stdout = cfg.stdout
+	session.Stderr = cfg.stderr
+
+	result := &RunResult{Cmd: cmd}
+	start := time.Now()
+	if err := session.Start(c.buildCommand(session, cmd, cfg)); err != nil {
+		return result, c.exitError(result, err)
+	}
+	if ctx.Done() == nil {
+		err = session.Wait()
+		result.Duration = time.Since(start)
+		return result, c.exitError(result, err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+	select {
+	case err = <-done:
+		result.Duration = time.Since(start)
+		return result, c.exitError(result, err)
+	case <-ctx.Done():
+	}
+	if err := session.Signal(ssh.SIGTERM); err != nil && c.Debug {
+		log.Println(err.Error())
+	}
+	select {
+	case err = <-done:
+		result.Duration = time.Since(start)
+		c.exitError(result, err)
+	case <-time.After(killGrace):
+		// 标准输入阻塞时 Wait 不会返回，关闭会话后不再等待
+		session.Close()
+		result.Duration = time.Since(start)
+		result.ExitCode = -1
+	}
+	return result, &sshError{kind: ErrRunCommandFail, err: ctx.Err()}
+}
+
+// newSessionContext 新建会话，ctx 结束时不再等待连接
+func (c *Cli) newSessionContext(ctx context.Context) (*ssh.Session, error) {
+	if ctx.Done() == nil {
+		return c.newSession()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, &sshError{kind: ErrConnectFail, err: err}
+	}
+	type reply struct {
+		session   *ssh.Session
+		client    *ssh.Client
+		connected bool
+		err       error
+	}
+	ch := make(chan reply, 1)
+	go func() {
+		session, client, connected, err := c.newSessionClient()
+		ch <- reply{session, client, connected, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.session, r.err
+	case <-ctx.Done():
+		go func() {
+			// 调用方已经返回，可能已经调用了 Close，关闭之后才建立的连接
+			r := <-ch
+			if r.session != nil {
+				r.session.Close()
+			}
+			if r.connected && r.client != nil {
+				c.resetClient(r.client)
+			}
+		}()
+		return nil, &sshError{kind: ErrConnectFail, err: ctx.Err()}
+	}
+}
+
+// buildCommand 设置环境变量和工作目录
+func (c *Cli) buildCommand(session *ssh.Session, cmd string, cfg *runConfig) string {
+	keys := make([]string, 0, len(cfg.env))
+	for k := range cfg.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var exports []string
+	for _, k := range keys {
+		if err := session.Setenv(k, cfg.env[k]); err != nil {
+			if c.Debug {
+				log.Println(err.Error())
+			}
+			exports = append(exports, "export "+k+"="+shellQuote(cfg.env[k])+";")
+		}
+	}
+	if cfg.dir != "" {
+		// 使用命令组，切换目录失败时复合命令的所有部分都不执行
+		cmd = "cd " + shellQuote(cfg.dir) + " && {\n" + cmd + "\n}"
+	}
+	if len(exports) > 0 {
+		cmd = strings.Join(exports, " ") + " " + cmd
+	}
+	return cmd
+}
@@ -0,0 +1,117 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snowlyg/helper/global/sshtest"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestRunContext(t *testing.T) {
+	t.Run("测试已取消的 ctx", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cli := NewSSHClient("127.0.0.1", "root", WithPassword("pwd"))
+		_, err := cli.RunContext(ctx, "uptime", WithEnv("LANG", "C"), WithDir("/tmp"))
+		if !errors.Is(err, ErrConnectFail) || !errors.Is(err, context.Canceled) {
+			t.Errorf("RunContext() want %v but get %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestRunContextServer(t *testing.T) {
+	srv := sshtest.NewServer(nil)
+	defer srv.Close()
+	cli := newTestCli(t, srv)
+	t.Run("测试环境变量和工作目录", func(t *testing.T) {
+		workDir := t.TempDir()
+		result, err := cli.RunContext(context.Background(), `echo "$GREETING"; pwd`, WithEnv("GREETING", "it's ok"), WithDir(workDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "it's ok\n" + workDir + "\n"
+		if result.Stdout != want {
+			t.Errorf("RunContext() want %q but get %q", want, result.Stdout)
+		}
+	})
+	t.Run("测试工作目录不存在", func(t *testing.T) {
+		result, err := cli.RunContext(context.Background(), "echo a; echo b || echo c", WithDir("/not/exist/dir"))
+		if err == nil {
+			t.Error("RunContext() want error but get nil")
+		}
+		if result != nil && result.Stdout != "" {
+			t.Errorf("RunContext() want no output but get %q", result.Stdout)
+		}
+	})
+	t.Run("测试环境变量名称错误", func(t *testing.T) {
+		for _, key := range []string{"A;rm -rf ~", "1A", "A-B", ""} {
+			if _, err := cli.RunContext(context.Background(), "true", WithEnv(key, "1")); !errors.Is(err, ErrEnvName) {
+				t.Errorf("RunContext(%q) want %v but get %v", key, ErrEnvName, err)
+			}
+		}
+	})
+	t.Run("测试标准输入", func(t *testing.T) {
+		result, err := cli.RunContext(context.Background(), "cat", WithStdin(strings.NewReader("input")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Stdout != "input" {
+			t.Errorf("RunContext() want %s but get %s", "input", result.Stdout)
+		}
+	})
+	t.Run("测试超时结束远程命令", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := cli.RunContext(ctx, "sleep 10")
+		if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrRunCommandFail) {
+			t.Errorf("RunContext() want %v but get %v", context.DeadlineExceeded, err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("RunContext() not canceled in time %v", time.Since(start))
+		}
+	})
+}
+
+func TestRunContextSlowHandshake(t *testing.T) {
+	srv := sshtest.NewUnstartedServer(nil)
+	srv.Config = func(config *ssh.ServerConfig) {
+		password := config.PasswordCallback
+		config.PasswordCallback = func(meta ssh.ConnMetadata, pwd []byte) (*ssh.Permissions, error) {
+			time.Sleep(300 * time.Millisecond)
+			return password(meta, pwd)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+	t.Run("测试握手时取消关闭之后建立的连接", func(t *testing.T) {
+		cli := newTestCli(t, srv)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := cli.RunContext(ctx, "true"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("RunContext() want %v but get %v", context.DeadlineExceeded, err)
+		}
+		deadline := time.Now().Add(3 * time.Second)
+		for srv.Connections() == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if srv.Connections() == 0 {
+			t.Fatal("handshake not finished")
+		}
+		// 握手完成之后连接会被关闭，建立连接时持有锁，不会读到建立过程中的状态
+		for time.Now().Before(deadline) {
+			cli.mu.Lock()
+			client := cli.client
+			cli.mu.Unlock()
+			if client == nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Error("late connection is not closed")
+	})
+}
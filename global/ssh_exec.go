@@ -0,0 +1,162 @@
+package global
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snowlyg/helper/i18n"
+	"golang.org/x/crypto/ssh"
+)
+
+// RunResult 命令执行结果
+type RunResult struct {
+	Cmd      string        //执行的命令
+	Stdout   string        //标准输出,流式执行时为空
+	Stderr   string        //标准错误,流式执行时为空
+	ExitCode int           //退出码,没有返回退出码时为-1
+	Signal   string        //导致命令退出的信号,例如 KILL
+	Duration time.Duration //执行耗时
+}
+
+// ExitError 远程命令非正常退出，兼容 ErrRunCommandFail 判断
+type ExitError struct {
+	Result *RunResult
+	Err    *ssh.ExitError
+}
+
+func (e *ExitError) Error() string {
+	return e.Localize(i18n.CurrentLang())
+}
+
+// Localize 指定语言的错误信息
+func (e *ExitError) Localize(lang i18n.Lang) string {
+	return fmt.Sprintf("%s: %s: %s", ErrRunCommandFail.Localize(lang), e.Result.Cmd, e.Err.Error())
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExitError) Is(target error) bool {
+	return target == ErrRunCommandFail
+}
+
+// Exec 执行命令，分别返回标准输出和标准错误
+// 命令退出码不为0时返回 *ExitError，同时返回执行结果
+// @param cmd shell脚本命令
+func (c *Cli) Exec(cmd string) (*RunResult, error) {
+	var stdout, stderr bytes.Buffer
+	result, err := c.exec(cmd, nil, &stdout, &stderr)
+	if result != nil {
+		result.Stdout = stdout.String()
+		result.Stderr = stderr.String()
+	}
+	return result, err
+}
+
+// RunWriter 执行命令，输出实时写入 stdout 和 stderr
+// @param cmd shell脚本命令
+// @param stdout 标准输出,可以为 nil
+// @param stderr 标准错误,可以为 nil
+func (c *Cli) RunWriter(cmd string, stdout, stderr io.Writer) (*RunResult, error) {
+	return c.exec(cmd, nil, stdout, stderr)
+}
+
+// RunStream 执行命令，按行回调输出，适合长时间运行的远程任务
+// 回调不会并发执行
+// @param cmd shell脚本命令
+// @param fn 行回调,isStderr 为 true 时该行来自标准错误
+func (c *Cli) RunStream(cmd string, fn func(line string, isStderr bool)) (*RunResult, error) {
+	var mu sync.Mutex
+	stdout := &lineWriter{mu: &mu, fn: func(line string) { fn(line, false) }}
+	stderr := &lineWriter{mu: &mu, fn: func(line string) { fn(line, true) }}
+	result, err := c.exec(cmd, nil, stdout, stderr)
+	stdout.Flush()
+	stderr.Flush()
+	return result, err
+}
+
+// exec 新建会话执行命令
+func (c *Cli) exec(cmd string, stdin io.Reader, stdout, stderr io.Writer) (*RunResult, error) {
+	return c.runContext(context.Background(), cmd, &runConfig{stdin: stdin, stdout: stdout, stderr: stderr})
+}
+
+// exitError 解析退出码和信号
+func (c *Cli) exitError(result *RunResult, err error) error {
+	if err == nil {
+		return nil
+	}
+	if c.Debug {
+		log.Println(err.Error())
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitStatus()
+		result.Signal = exitErr.Signal()
+		return &ExitError{Result: result, Err: exitErr}
+	}
+	result.ExitCode = -1
+	return &sshError{kind: ErrRunCommandFail, err: err}
+}
+
+// lineWriter 按行回调的 io.Writer
+type lineWriter struct {
+	mu  *sync.Mutex
+	fn  func(line string)
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush 回调最后一行没有换行符的输出
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.fn(strings.TrimSuffix(string(w.buf), "\r"))
+		w.buf = nil
+	}
+}
+
+// lockedBuffer 标准输出和标准错误共用的缓冲区
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// shellQuote 使用单引号转义 shell 参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
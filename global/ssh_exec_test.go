@@ -0,0 +1,39 @@
+package global
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	t.Run("测试按行回调输出", func(t *testing.T) {
+		var lines []string
+		w := &lineWriter{mu: &sync.Mutex{}, fn: func(line string) { lines = append(lines, line) }}
+		w.Write([]byte("first\r\nsec"))
+		w.Write([]byte("ond\n\nlast"))
+		w.Flush()
+		want := []string{"first", "second", "", "last"}
+		if !reflect.DeepEqual(lines, want) {
+			t.Errorf("lineWriter want %v but get %v", want, lines)
+		}
+	})
+}
+
+func TestExitError(t *testing.T) {
+	t.Run("测试命令执行失败错误", func(t *testing.T) {
+		cli := NewSSH("127.0.0.1", "root", "", false)
+		result := &RunResult{Cmd: "false"}
+		err := cli.exitError(result, errors.New("ssh: command false exited without exit status or exit signal"))
+		if !errors.Is(err, ErrRunCommandFail) {
+			t.Errorf("exitError() want %v but get %v", ErrRunCommandFail, err)
+		}
+		if result.ExitCode != -1 {
+			t.Errorf("exitError() exit code want %d but get %d", -1, result.ExitCode)
+		}
+		if cli.exitError(result, nil) != nil {
+			t.Error("exitError() with nil want nil")
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package global
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/snowlyg/helper/dir"
+	"github.com/snowlyg/helper/i18n"
+)
+
+var ErrInventoryHostIP = i18n.NewError("ssh.inventory_host_ip")
+
+// FleetStatus 主机执行状态
+type FleetStatus string
+
+const (
+	FleetSucceeded   FleetStatus = "succeeded"   //执行成功
+	FleetFailed      FleetStatus = "failed"      //命令执行失败或者超时
+	FleetUnreachable FleetStatus = "unreachable" //连接失败或者未执行
+)
+
+// FleetHost 主机清单中的主机
+type FleetHost struct {
+	Name       string            `yaml:"name"`       //名称,为空时使用IP
+	IP         string            `yaml:"ip"`         //IP地址
+	Port       int               `yaml:"port"`       //端口号,默认22
+	Username   string            `yaml:"username"`   //用户名
+	Password   string            `yaml:"password"`   //密码
+	KeyFile    string            `yaml:"key_file"`   //私钥文件
+	Passphrase string            `yaml:"passphrase"` //私钥密码
+	Vars       map[string]string `yaml:"vars"`       //主机变量
+}
+
+// Inventory 主机清单
+type Inventory struct {
+	Defaults FleetHost   `yaml:"defaults"` //默认配置,主机未配置的字段使用默认配置
+	Hosts    []FleetHost `yaml:"hosts"`
+}
+
+// LoadInventory 从 yaml 文件加载主机清单
+//
+//	defaults:
+//	  username: root
+//	  port: 22
+//	hosts:
+//	  - name: dev-1
+//	    ip: 10.0.1.14
+//	    password: xxx
+func LoadInventory(path string) (*Inventory, error) {
+	inv := &Inventory{}
+	if err := dir.ReadYaml(path, inv); err != nil {
+		return nil, err
+	}
+	for i := range inv.Hosts {
+		inv.Hosts[i] = inv.Hosts[i].withDefaults(inv.Defaults)
+		if inv.Hosts[i].IP == "" {
+			return nil, fmt.Errorf("%w: %s hosts[%d]", ErrInventoryHostIP, path, i)
+		}
+	}
+	return inv, nil
+}
+
+// withDefaults 使用默认配置补全主机配置
+func (h FleetHost) withDefaults(def FleetHost) FleetHost {
+	if h.Port == 0 {
+		h.Port = def.Port
+	}
+	if h.Username == "" {
+		h.Username = def.Username
+	}
+	if h.Password == "" {
+		h.Password = def.Password
+	}
+	if h.KeyFile == "" {
+		h.KeyFile = def.KeyFile
+	}
+	if h.Passphrase == "" {
+		h.Passphrase = def.Passphrase
+	}
+	if h.Name == "" {
+		h.Name = h.IP
+	}
+	vars := make(map[string]string, len(def.Vars)+len(h.Vars))
+	for k, v := range def.Vars {
+		vars[k] = v
+	}
+	for k, v := range h.Vars {
+		vars[k] = v
+	}
+	h.Vars = vars
+	return h
+}
+
+// Cli 创建主机的命令行对象
+func (h FleetHost) Cli(opts ...SSHOption) *Cli {
+	hostOpts := []SSHOption{}
+	if h.Port > 0 {
+		hostOpts = append(hostOpts, WithPort(h.Port))
+	}
+	if h.KeyFile != "" {
+		hostOpts = append(hostOpts, WithPrivateKeyFile(h.KeyFile, h.Passphrase))
+	}
+	if h.Password != "" {
+		hostOpts = append(hostOpts, WithPassword(h.Password))
+	}
+	return NewSSHClient(h.IP, h.Username, append(hostOpts, opts...)...)
+}
+
+// FleetResult 单个主机执行结果
+type FleetResult struct {
+	Host   FleetHost
+	Status FleetStatus
+	Result *RunResult
+	Err    error
+}
+
+// FleetSummary 执行结果汇总
+type FleetSummary struct {
+	Total       int
+	Succeeded   int
+	Failed      int
+	Unreachable int
+	Duration    time.Duration
+}
+
+// FleetReport 批量执行报告，Results 顺序和主机顺序一致
+type FleetReport struct {
+	Results []FleetResult
+	Summary FleetSummary
+}
+
+// 默认并发数
+const defaultConcurrency = 10
+
+// Fleet 多主机并发执行命令
+type Fleet struct {
+	Hosts       []FleetHost
+	Concurrency int           //并发数,默认10
+	Timeout     time.Duration //单个主机超时时间,默认60秒,包含连接时间
+	Options     []SSHOption   //所有主机通用的配置项
+}
+
+// NewFleet 使用主机清单创建
+func NewFleet(inv *Inventory, opts ...SSHOption) *Fleet {
+	return &Fleet{Hosts: inv.Hosts, Concurrency: defaultConcurrency, Timeout: 60 * time.Second, Options: opts}
+}
+
+// Run 在所有主机上执行命令
+func (f *Fleet) Run(ctx context.Context, cmd string) *FleetReport {
+	return f.each(ctx, func(ctx context.Context, host FleetHost, cli *Cli) (*RunResult, error) {
+		return cli.RunContext(ctx, cmd)
+	})
+}
+
+// RunScript 在所有主机上执行本地脚本，脚本内容通过标准输入传给解释器
+// @param scriptPath 本地脚本路径
+// @param interpreter 解释器,默认 sh
+func (f *Fleet) RunScript(ctx context.Context, scriptPath string, interpreter ...string) (*FleetReport, error) {
+	script, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return nil, err
+	}
+	cmd := "sh -s"
+	if len(interpreter) > 0 && interpreter[0] != "" {
+		cmd = interpreter[0] + " -s"
+	}
+	return f.each(ctx, func(ctx context.Context, host FleetHost, cli *Cli) (*RunResult, error) {
+		return cli.RunContext(ctx, cmd, WithStdin(bytes.NewReader(script)))
+	}), nil
+}
+
+// RunTemplate 在所有主机上执行脚本模版，使用主机变量渲染
+// 模版中 .Name 和 .IP 为主机名称和IP，其他变量来自主机清单的 vars
+func (f *Fleet) RunTemplate(ctx context.Context, script *Script, opts ...RunOption) *FleetReport {
+	return f.each(ctx, func(ctx context.Context, host FleetHost, cli *Cli) (*RunResult, error) {
+		return cli.RunScript(ctx, script, host.templateVars(), opts...)
+	})
+}
+
+// templateVars 脚本模版变量
+func (h FleetHost) templateVars() map[string]interface{} {
+	vars := make(map[string]interface{}, len(h.Vars)+2)
+	vars["Name"] = h.Name
+	vars["IP"] = h.IP
+	for k, v := range h.Vars {
+		vars[k] = v
+	}
+	return vars
+}
+
+// each 并发执行
+func (f *Fleet) each(ctx context.Context, run func(ctx context.Context, host FleetHost, cli *Cli) (*RunResult, error)) *FleetReport {
+	start := time.Now()
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	report := &FleetReport{Results: make([]FleetResult, len(f.Hosts))}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, host := range f.Hosts {
+		wg.Add(1)
+		go func(i int, host FleetHost) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			report.Results[i] = f.runHost(ctx, host, run)
+		}(i, host)
+	}
+	wg.Wait()
+
+	report.Summary.Total = len(report.Results)
+	for _, result := range report.Results {
+		switch result.Status {
+		case FleetSucceeded:
+			report.Summary.Succeeded++
+		case FleetFailed:
+			report.Summary.Failed++
+		case FleetUnreachable:
+			report.Summary.Unreachable++
+		}
+	}
+	report.Summary.Duration = time.Since(start)
+	return report
+}
+
+// runHost 单个主机执行
+func (f *Fleet) runHost(ctx context.Context, host FleetHost, run func(ctx context.Context, host FleetHost, cli *Cli) (*RunResult, error)) FleetResult {
+	result := FleetResult{Host: host}
+	if err := ctx.Err(); err != nil {
+		result.Status, result.Err = FleetUnreachable, err
+		return result
+	}
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cli := host.Cli(f.Options...)
+	defer cli.Close()
+	result.Result, result.Err = run(ctx, host, cli)
+	switch {
+	case result.Err == nil:
+		result.Status = FleetSucceeded
+	case errors.Is(result.Err, ErrConnectFail):
+		result.Status = FleetUnreachable
+	default:
+		result.Status = FleetFailed
+	}
+	return result
+}
@@ -0,0 +1,140 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/snowlyg/helper/dir"
+	"github.com/snowlyg/helper/global/sshtest"
+)
+
+var inventoryYaml = `
+defaults:
+  username: root
+  password: pwd
+  port: 2022
+  vars:
+    env: prod
+hosts:
+  - name: dev-1
+    ip: 10.0.1.14
+  - ip: 10.0.1.15
+    port: 22
+    vars:
+      env: test
+`
+
+func TestLoadInventory(t *testing.T) {
+	t.Run("测试加载主机清单", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "hosts.yaml")
+		if _, err := dir.WriteString(path, inventoryYaml); err != nil {
+			t.Fatal(err)
+		}
+		inv, err := LoadInventory(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inv.Hosts) != 2 {
+			t.Fatalf("LoadInventory() hosts want %d but get %d", 2, len(inv.Hosts))
+		}
+		first, second := inv.Hosts[0], inv.Hosts[1]
+		if first.Port != 2022 || first.Username != "root" || first.Password != "pwd" || first.Vars["env"] != "prod" {
+			t.Errorf("LoadInventory() defaults not applied %+v", first)
+		}
+		if second.Name != "10.0.1.15" || second.Port != 22 || second.Vars["env"] != "test" {
+			t.Errorf("LoadInventory() host config not applied %+v", second)
+		}
+	})
+	t.Run("测试主机清单缺少ip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "hosts.yaml")
+		if _, err := dir.WriteString(path, "hosts:\n  - name: dev-1\n"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadInventory(path); !errors.Is(err, ErrInventoryHostIP) {
+			t.Errorf("LoadInventory() want %v but get %v", ErrInventoryHostIP, err)
+		}
+	})
+}
+
+func TestFleetRun(t *testing.T) {
+	t.Run("测试主机不可达", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+		hosts := []FleetHost{
+			{Name: "a", IP: "127.0.0.1", Port: port, Username: "root", Password: "pwd"},
+			{Name: "b", IP: "127.0.0.1", Port: port, Username: "root", Password: "pwd"},
+		}
+		fleet := NewFleet(&Inventory{Hosts: hosts})
+		fleet.Timeout = 3 * time.Second
+		report := fleet.Run(context.Background(), "uptime")
+		if report.Summary.Total != 2 || report.Summary.Unreachable != 2 {
+			t.Errorf("Fleet.Run() summary want 2 unreachable but get %+v", report.Summary)
+		}
+		for i, result := range report.Results {
+			if result.Host.Name != hosts[i].Name || result.Status != FleetUnreachable {
+				t.Errorf("Fleet.Run() result %d get %+v", i, result)
+			}
+		}
+	})
+}
+
+func TestFleetRunServer(t *testing.T) {
+	mux := sshtest.NewMux()
+	mux.Handle("uptime", sshtest.Output("up 1 day\n", "", 0))
+	var hosts []FleetHost
+	for i := 0; i < 3; i++ {
+		srv := sshtest.NewServer(mux.Serve)
+		defer srv.Close()
+		hosts = append(hosts, FleetHost{Name: strconv.Itoa(i), IP: srv.Host(), Port: srv.Port(), Username: sshtest.DefaultUser, Password: sshtest.DefaultPassword})
+	}
+	hosts[2].Password = "wrong"
+	t.Run("测试批量执行命令", func(t *testing.T) {
+		report := NewFleet(&Inventory{Hosts: hosts}).Run(context.Background(), "uptime")
+		if report.Summary.Succeeded != 2 || report.Summary.Unreachable != 1 {
+			t.Errorf("Fleet.Run() summary get %+v", report.Summary)
+		}
+		if report.Results[0].Result.Stdout != "up 1 day\n" {
+			t.Errorf("Fleet.Run() stdout get %q", report.Results[0].Result.Stdout)
+		}
+	})
+	t.Run("测试批量执行失败", func(t *testing.T) {
+		report := NewFleet(&Inventory{Hosts: hosts[:2]}).Run(context.Background(), "reboot")
+		if report.Summary.Failed != 2 {
+			t.Errorf("Fleet.Run() summary get %+v", report.Summary)
+		}
+	})
+}
+
+func TestFleetRunTemplate(t *testing.T) {
+	var hosts []FleetHost
+	for i := 0; i < 2; i++ {
+		srv := sshtest.NewServer(nil)
+		defer srv.Close()
+		hosts = append(hosts, FleetHost{Name: "dev-" + strconv.Itoa(i), IP: srv.Host(), Port: srv.Port(), Username: sshtest.DefaultUser, Password: sshtest.DefaultPassword, Vars: map[string]string{"env": "prod"}})
+	}
+	hosts[1].Vars["env"] = "test"
+	t.Run("测试批量执行脚本模版", func(t *testing.T) {
+		script, err := NewScript("hello.sh", "echo {{.Name}} {{.env}}")
+		if err != nil {
+			t.Fatal(err)
+		}
+		report := NewFleet(&Inventory{Hosts: hosts}).RunTemplate(context.Background(), script)
+		if report.Summary.Succeeded != 2 {
+			t.Fatalf("Fleet.RunTemplate() summary get %+v", report.Summary)
+		}
+		for i, want := range []string{"dev-0 prod\n", "dev-1 test\n"} {
+			if got := report.Results[i].Result.Stdout; got != want {
+				t.Errorf("Fleet.RunTemplate() %d want %q but get %q", i, want, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,282 @@
+package global
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var ErrSocks5Handshake = i18n.NewError("ssh.socks5_handshake")
+
+// Forward 端口转发，调用 Close 停止监听并关闭所有转发中的连接
+type Forward struct {
+	listener net.Listener
+	debug    bool
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// Addr 监听地址
+func (f *Forward) Addr() net.Addr {
+	return f.listener.Addr()
+}
+
+// Close 停止转发
+func (f *Forward) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	err := f.listener.Close()
+	for conn := range f.conns {
+		conn.Close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+	return err
+}
+
+// LocalForward 本地端口转发，和 ssh -L 一致
+// @param localAddr 本地监听地址，例如 127.0.0.1:8080，端口为0时随机分配
+// @param remoteAddr 通过 ssh 服务端访问的地址，例如 127.0.0.1:80
+func (c *Cli) LocalForward(localAddr, remoteAddr string) (*Forward, error) {
+	ln, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		return nil, err
+	}
+	f := c.newForward(ln)
+	go f.serve(func(conn net.Conn) (net.Conn, error) {
+		client, err := c.Client()
+		if err != nil {
+			return nil, err
+		}
+		return client.Dial("tcp", remoteAddr)
+	})
+	return f, nil
+}
+
+// RemoteForward 远程端口转发，和 ssh -R 一致
+// @param remoteAddr ssh 服务端监听地址，例如 127.0.0.1:8080
+// @param localAddr 本地访问的地址，例如 127.0.0.1:80
+func (c *Cli) RemoteForward(remoteAddr, localAddr string) (*Forward, error) {
+	client, err := c.Client()
+	if err != nil {
+		return nil, err
+	}
+	ln, err := client.Listen("tcp", remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+	f := c.newForward(ln)
+	go f.serve(func(conn net.Conn) (net.Conn, error) {
+		return net.Dial("tcp", localAddr)
+	})
+	return f, nil
+}
+
+// DynamicForward 动态端口转发，本地提供 SOCKS5 代理，和 ssh -D 一致
+// 只支持无认证的 CONNECT 命令
+// @param localAddr 本地监听地址，例如 127.0.0.1:1080
+func (c *Cli) DynamicForward(localAddr string) (*Forward, error) {
+	ln, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		return nil, err
+	}
+	f := c.newForward(ln)
+	go f.serve(func(conn net.Conn) (net.Conn, error) {
+		target, err := socks5Handshake(conn)
+		if err != nil {
+			return nil, err
+		}
+		client, err := c.Client()
+		if err != nil {
+			socks5Reply(conn, socks5HostUnreachable)
+			return nil, err
+		}
+		remote, err := client.Dial("tcp", target)
+		if err != nil {
+			socks5Reply(conn, socks5HostUnreachable)
+			return nil, err
+		}
+		if err := socks5Reply(conn, socks5Succeeded); err != nil {
+			remote.Close()
+			return nil, err
+		}
+		return remote, nil
+	})
+	return f, nil
+}
+
+func (c *Cli) newForward(ln net.Listener) *Forward {
+	return &Forward{listener: ln, debug: c.Debug, conns: map[net.Conn]struct{}{}}
+}
+
+// serve 接收连接并转发
+// @param dial 根据接收的连接建立转发目标连接
+func (f *Forward) serve(dial func(conn net.Conn) (net.Conn, error)) {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			if f.debug && !f.isClosed() {
+				log.Println(err.Error())
+			}
+			return
+		}
+		if !f.track(conn, true) {
+			conn.Close()
+			return
+		}
+		go func() {
+			defer f.wg.Done()
+			defer f.untrack(conn)
+			target, err := dial(conn)
+			if err != nil {
+				if f.debug {
+					log.Println(err.Error())
+				}
+				return
+			}
+			if !f.track(target, false) {
+				target.Close()
+				return
+			}
+			defer f.untrack(target)
+			pipe(conn, target)
+		}()
+	}
+}
+
+func (f *Forward) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// track 记录连接，Close 时关闭，已关闭时返回 false
+// @param wait 是否需要 Close 等待连接的转发结束,在锁内调用 wg.Add 避免和 Close 的 wg.Wait 并发
+func (f *Forward) track(conn net.Conn, wait bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return false
+	}
+	f.conns[conn] = struct{}{}
+	if wait {
+		f.wg.Add(1)
+	}
+	return true
+}
+
+func (f *Forward) untrack(conn net.Conn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	conn.Close()
+	delete(f.conns, conn)
+}
+
+// pipe 双向复制数据，任意一端结束后关闭两端
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	cp := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go cp(a, b)
+	go cp(b, a)
+	<-done
+	a.Close()
+	b.Close()
+	<-done
+}
+
+// SOCKS5 应答码
+const (
+	socks5Succeeded          byte = 0x00
+	socks5HostUnreachable    byte = 0x04
+	socks5CommandUnsupported byte = 0x07
+	socks5AddrUnsupported    byte = 0x08
+)
+
+// socks5Handshake SOCKS5 握手，返回 CONNECT 目标地址
+func socks5Handshake(conn net.Conn) (string, error) {
+	buf := make([]byte, 262)
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", err
+	}
+	if buf[0] != 0x05 {
+		return "", fmt.Errorf("%w: %s", ErrSocks5Handshake, i18n.Message("ssh.socks5_version", buf[0]))
+	}
+	methods := buf[1 : 2+int(buf[1])]
+	if _, err := io.ReadFull(conn, methods[1:]); err != nil {
+		return "", err
+	}
+	noAuth := false
+	for _, m := range methods[1:] {
+		if m == 0x00 {
+			noAuth = true
+		}
+	}
+	if !noAuth {
+		conn.Write([]byte{0x05, 0xff})
+		return "", fmt.Errorf("%w: %s", ErrSocks5Handshake, i18n.Message("ssh.socks5_auth"))
+	}
+	if _, err := conn.Write([]byte{0x05, 0x00}); err != nil {
+		return "", err
+	}
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return "", err
+	}
+	if buf[0] != 0x05 {
+		return "", fmt.Errorf("%w: %s", ErrSocks5Handshake, i18n.Message("ssh.socks5_version", buf[0]))
+	}
+	if buf[1] != 0x01 {
+		socks5Reply(conn, socks5CommandUnsupported)
+		return "", fmt.Errorf("%w: %s", ErrSocks5Handshake, i18n.Message("ssh.socks5_command", buf[1]))
+	}
+	var host string
+	switch buf[3] {
+	case 0x01:
+		if _, err := io.ReadFull(conn, buf[:net.IPv4len]); err != nil {
+			return "", err
+		}
+		host = net.IP(buf[:net.IPv4len]).String()
+	case 0x03:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return "", err
+		}
+		l := int(buf[0])
+		if _, err := io.ReadFull(conn, buf[:l]); err != nil {
+			return "", err
+		}
+		host = string(buf[:l])
+	case 0x04:
+		if _, err := io.ReadFull(conn, buf[:net.IPv6len]); err != nil {
+			return "", err
+		}
+		host = net.IP(buf[:net.IPv6len]).String()
+	default:
+		socks5Reply(conn, socks5AddrUnsupported)
+		return "", fmt.Errorf("%w: %s", ErrSocks5Handshake, i18n.Message("ssh.socks5_addr_type", buf[3]))
+	}
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return "", err
+	}
+	port := binary.BigEndian.Uint16(buf[:2])
+	return net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+}
+
+// socks5Reply SOCKS5 应答，绑定地址固定为 0.0.0.0:0
+func socks5Reply(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{0x05, code, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,217 @@
+package global
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/snowlyg/helper/global/sshtest"
+)
+
+func TestSocks5Handshake(t *testing.T) {
+	tests := []struct {
+		name    string
+		request []byte
+		want    string
+	}{
+		{name: "ipv4", request: []byte{0x05, 0x01, 0x00, 0x01, 10, 0, 1, 14, 0x00, 0x16}, want: "10.0.1.14:22"},
+		{name: "domain", request: append(append([]byte{0x05, 0x01, 0x00, 0x03, 9}, "localhost"...), 0x1f, 0x90), want: "localhost:8080"},
+		{name: "ipv6", request: []byte{0x05, 0x01, 0x00, 0x04, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0x00, 0x50}, want: "[::1]:80"},
+	}
+	for _, tt := range tests {
+		t.Run("测试 SOCKS5 握手:"+tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+			go func() {
+				client.Write([]byte{0x05, 0x02, 0x02, 0x00})
+				reply := make([]byte, 2)
+				io.ReadFull(client, reply)
+				if !bytes.Equal(reply, []byte{0x05, 0x00}) {
+					t.Errorf("socks5 method reply get %v", reply)
+				}
+				client.Write(tt.request)
+			}()
+			target, err := socks5Handshake(server)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target != tt.want {
+				t.Errorf("socks5Handshake() want %s but get %s", tt.want, target)
+			}
+		})
+	}
+	t.Run("测试 SOCKS5 不支持的命令", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer client.Close()
+		defer server.Close()
+		go func() {
+			client.Write([]byte{0x05, 0x01, 0x00})
+			io.ReadFull(client, make([]byte, 2))
+			client.Write([]byte{0x05, 0x02, 0x00, 0x01})
+			io.ReadFull(client, make([]byte, 10))
+		}()
+		_, err := socks5Handshake(server)
+		if !errors.Is(err, ErrSocks5Handshake) {
+			t.Fatalf("socks5Handshake() want %v but get %v", ErrSocks5Handshake, err)
+		}
+		if got := err.Error(); got != "SOCKS5 握手失败: 不支持的命令 2" {
+			t.Errorf("Error() get %s", got)
+		}
+	})
+}
+
+func TestWithJumpHost(t *testing.T) {
+	t.Run("测试多个跳板机", func(t *testing.T) {
+		first := NewSSH("10.0.0.1", "root", "", false)
+		second := NewSSH("10.0.0.2", "root", "", false)
+		cli := NewSSHClient("10.0.1.14", "root", WithJumpHost(first, second))
+		if len(cli.jumps) != 2 || cli.jumps[0] != first || cli.jumps[1] != second {
+			t.Error("WithJumpHost() chain is wrong")
+		}
+		if first.jumps != nil || second.jumps != nil {
+			t.Error("WithJumpHost() want jump hosts unchanged")
+		}
+	})
+	t.Run("测试跳板机不同顺序", func(t *testing.T) {
+		first := NewSSH("10.0.0.1", "root", "", false)
+		second := NewSSH("10.0.0.2", "root", "", false)
+		a := NewSSHClient("10.0.1.14", "root", WithJumpHost(first, second))
+		b := NewSSHClient("10.0.1.15", "root", WithJumpHost(second, first))
+		if a.jumps[1] != second || b.jumps[1] != first {
+			t.Error("WithJumpHost() want independent chains")
+		}
+	})
+}
+
+// newEchoServer 本地回显服务
+func newEchoServer(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// assertEcho 检查连接能够回显数据
+func assertEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("echo want %s but get %s", "ping", buf)
+	}
+}
+
+func TestForward(t *testing.T) {
+	srv := sshtest.NewServer(nil)
+	defer srv.Close()
+	echo := newEchoServer(t)
+	cli := newTestCli(t, srv)
+	t.Run("测试本地端口转发", func(t *testing.T) {
+		f, err := cli.LocalForward("127.0.0.1:0", echo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		conn, err := net.Dial("tcp", f.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEcho(t, conn)
+	})
+	t.Run("测试远程端口转发", func(t *testing.T) {
+		f, err := cli.RemoteForward("127.0.0.1:0", echo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		conn, err := net.Dial("tcp", f.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEcho(t, conn)
+	})
+	t.Run("测试动态端口转发", func(t *testing.T) {
+		f, err := cli.DynamicForward("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		conn, err := net.Dial("tcp", f.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, port, _ := net.SplitHostPort(echo)
+		p, _ := strconv.Atoi(port)
+		req := append([]byte{0x05, 0x01, 0x00, 0x05, 0x01, 0x00, 0x01}, net.ParseIP(host).To4()...)
+		conn.Write(append(req, byte(p>>8), byte(p)))
+		reply := make([]byte, 12)
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply[3] != socks5Succeeded {
+			t.Fatalf("socks5 reply want %d but get %d", socks5Succeeded, reply[3])
+		}
+		assertEcho(t, conn)
+	})
+	t.Run("测试跳板机", func(t *testing.T) {
+		target := sshtest.NewServer(sshtest.Output("target\n", "", 0))
+		defer target.Close()
+		jump := newTestCli(t, srv)
+		cli := newTestCli(t, target, WithJumpHost(jump))
+		out, err := cli.Run("hostname")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "target\n" {
+			t.Errorf("Run() via jump host want %q but get %q", "target\n", out)
+		}
+	})
+	t.Run("测试多个跳板机", func(t *testing.T) {
+		second := sshtest.NewServer(nil)
+		defer second.Close()
+		target := sshtest.NewServer(sshtest.Output("target\n", "", 0))
+		defer target.Close()
+		first := newTestCli(t, srv)
+		hop := newTestCli(t, second)
+		cli := newTestCli(t, target, WithJumpHost(first, hop))
+		out, err := cli.Run("hostname")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "target\n" {
+			t.Errorf("Run() via jump hosts want %q but get %q", "target\n", out)
+		}
+		if second.Connections() != 1 {
+			t.Errorf("second jump host connections want 1 but get %d", second.Connections())
+		}
+		if hop.client != nil {
+			t.Error("second jump host want no direct connection")
+		}
+	})
+}
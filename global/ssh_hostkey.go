@@ -0,0 +1,192 @@
+package global
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/snowlyg/helper/i18n"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+var (
+	ErrHostKeyUnknown  = i18n.NewError("ssh.host_key_unknown")
+	ErrHostKeyMismatch = i18n.NewError("ssh.host_key_mismatch")
+	ErrHostKeyRevoked  = i18n.NewError("ssh.host_key_revoked")
+)
+
+// knownHostsMu 信任首次连接时追加 known_hosts 文件的锁
+var knownHostsMu sync.Mutex
+
+// HostKeyError 主机密钥校验失败
+type HostKeyError struct {
+	Host string                // 连接地址
+	Key  ssh.PublicKey         // 服务端返回的密钥
+	Want []knownhosts.KnownKey // known_hosts 中记录的密钥，密钥变更时不为空
+	Err  error                 // ErrHostKeyUnknown, ErrHostKeyMismatch 或 ErrHostKeyRevoked
+}
+
+func (e *HostKeyError) Error() string {
+	return e.Localize(i18n.CurrentLang())
+}
+
+// Localize 指定语言的错误信息
+func (e *HostKeyError) Localize(lang i18n.Lang) string {
+	msg := fmt.Sprintf("%s: %s %s %s", i18n.Localize(e.Err, lang), e.Host, e.Key.Type(), ssh.FingerprintSHA256(e.Key))
+	for _, want := range e.Want {
+		msg += i18n.Sprintf(lang, "ssh.host_key_known", want.Key.Type(), ssh.FingerprintSHA256(want.Key), want.Filename, want.Line)
+	}
+	return msg
+}
+
+func (e *HostKeyError) Unwrap() error {
+	return e.Err
+}
+
+// Changed 主机密钥是否发生变更
+func (e *HostKeyError) Changed() bool {
+	return errors.Is(e.Err, ErrHostKeyMismatch)
+}
+
+// DefaultKnownHostsFile 默认 known_hosts 文件 ~/.ssh/known_hosts
+func DefaultKnownHostsFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// WithKnownHosts 使用 known_hosts 文件校验主机密钥，未知主机拒绝连接
+// @param files known_hosts 文件，默认 ~/.ssh/known_hosts
+func WithKnownHosts(files ...string) SSHOption {
+	// 在配置项外计算默认值，同一个配置项可以并发用于多个 Cli
+	if len(files) == 0 {
+		files = []string{DefaultKnownHostsFile()}
+	}
+	return func(c *Cli) {
+		c.hostKey = func() (ssh.HostKeyCallback, error) {
+			return knownHostsCallback(files, "")
+		}
+	}
+}
+
+// WithTrustOnFirstUse 首次连接信任主机密钥并追加到 known_hosts 文件，之后按文件校验
+// @param file known_hosts 文件，为空时使用 ~/.ssh/known_hosts
+func WithTrustOnFirstUse(file string) SSHOption {
+	if file == "" {
+		file = DefaultKnownHostsFile()
+	}
+	return func(c *Cli) {
+		c.hostKey = func() (ssh.HostKeyCallback, error) {
+			if err := insureKnownHostsFile(file); err != nil {
+				return nil, err
+			}
+			return knownHostsCallback([]string{file}, file)
+		}
+	}
+}
+
+// WithFingerprint 使用固定指纹校验主机密钥
+// @param fingerprints 指纹，支持 SHA256:xxx 和 MD5 格式 (MD5:xx:xx 或 xx:xx)
+func WithFingerprint(fingerprints ...string) SSHOption {
+	return func(c *Cli) {
+		c.hostKey = func() (ssh.HostKeyCallback, error) {
+			return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				sha := ssh.FingerprintSHA256(key)
+				md5 := ssh.FingerprintLegacyMD5(key)
+				for _, fp := range fingerprints {
+					fp = strings.TrimSpace(fp)
+					if fp == sha || strings.TrimPrefix(fp, "MD5:") == md5 {
+						return nil
+					}
+				}
+				return &HostKeyError{Host: hostname, Key: key, Err: ErrHostKeyMismatch}
+			}, nil
+		}
+	}
+}
+
+// WithHostKeyCallback 自定义主机密钥校验
+func WithHostKeyCallback(callback ssh.HostKeyCallback) SSHOption {
+	return func(c *Cli) {
+		c.hostKey = func() (ssh.HostKeyCallback, error) {
+			return callback, nil
+		}
+	}
+}
+
+// hostKeyCallback 主机密钥校验，未配置时不校验
+func (c *Cli) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if c.hostKey == nil {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	return c.hostKey()
+}
+
+// knownHostsCallback known_hosts 校验
+// @param files known_hosts 文件
+// @param appendFile 不为空时，未知主机密钥追加到该文件
+func knownHostsCallback(files []string, appendFile string) (ssh.HostKeyCallback, error) {
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("known_hosts %w", err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		if err == nil {
+			return nil
+		}
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			if len(keyErr.Want) > 0 {
+				return &HostKeyError{Host: hostname, Key: key, Want: keyErr.Want, Err: ErrHostKeyMismatch}
+			}
+			if appendFile != "" {
+				return appendKnownHost(appendFile, hostname, remote, key)
+			}
+			return &HostKeyError{Host: hostname, Key: key, Err: ErrHostKeyUnknown}
+		}
+		var revokedErr *knownhosts.RevokedError
+		if errors.As(err, &revokedErr) {
+			return &HostKeyError{Host: hostname, Key: key, Err: ErrHostKeyRevoked}
+		}
+		return err
+	}, nil
+}
+
+// insureKnownHostsFile 新建不存在的 known_hosts 文件
+func insureKnownHostsFile(file string) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// appendKnownHost 追加主机密钥到 known_hosts 文件
+func appendKnownHost(file, hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	addresses := []string{knownhosts.Normalize(hostname)}
+	if remote != nil && knownhosts.Normalize(remote.String()) != addresses[0] {
+		addresses = append(addresses, knownhosts.Normalize(remote.String()))
+	}
+	_, err = f.WriteString(knownhosts.Line(addresses, key) + "\n")
+	return err
+}
@@ -0,0 +1,139 @@
+package global
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/snowlyg/helper/global/sshtest"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestPublicKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestWithTrustOnFirstUse(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".ssh", "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.1.14"), Port: 2022}
+	key := newTestPublicKey(t)
+	cli := NewSSHClient("10.0.1.14", "root", WithTrustOnFirstUse(file))
+	t.Run("测试首次连接信任主机密钥", func(t *testing.T) {
+		callback, err := cli.hostKeyCallback()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := callback(remote.String(), remote, key); err != nil {
+			t.Errorf("first use want nil but get %v", err)
+		}
+	})
+	t.Run("测试已信任主机密钥", func(t *testing.T) {
+		callback, err := NewSSHClient("10.0.1.14", "root", WithKnownHosts(file)).hostKeyCallback()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := callback(remote.String(), remote, key); err != nil {
+			t.Errorf("known host want nil but get %v", err)
+		}
+	})
+	t.Run("测试主机密钥变更", func(t *testing.T) {
+		callback, err := cli.hostKeyCallback()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = callback(remote.String(), remote, newTestPublicKey(t))
+		var hostKeyErr *HostKeyError
+		if !errors.As(err, &hostKeyErr) || !hostKeyErr.Changed() {
+			t.Errorf("changed host key want %v but get %v", ErrHostKeyMismatch, err)
+		}
+	})
+}
+
+func TestWithKnownHosts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	if err := insureKnownHostsFile(file); err != nil {
+		t.Fatal(err)
+	}
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.1.14"), Port: 22}
+	t.Run("测试未知主机", func(t *testing.T) {
+		callback, err := NewSSHClient("10.0.1.14", "root", WithKnownHosts(file)).hostKeyCallback()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := callback(remote.String(), remote, newTestPublicKey(t)); !errors.Is(err, ErrHostKeyUnknown) {
+			t.Errorf("unknown host want %v but get %v", ErrHostKeyUnknown, err)
+		}
+	})
+	t.Run("测试文件不存在", func(t *testing.T) {
+		_, err := NewSSHClient("10.0.1.14", "root", WithKnownHosts(file+".not_exist")).hostKeyCallback()
+		if err == nil {
+			t.Error("not exist known_hosts want error but get nil")
+		}
+	})
+	t.Run("测试默认文件配置项并发使用", func(t *testing.T) {
+		t.Setenv("HOME", t.TempDir())
+		opts := []SSHOption{WithKnownHosts(), WithTrustOnFirstUse("")}
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				NewSSHClient("10.0.1.14", "root", opts...)
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestWithFingerprint(t *testing.T) {
+	key := newTestPublicKey(t)
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.1.14"), Port: 22}
+	fingerprints := []string{ssh.FingerprintSHA256(key), "MD5:" + ssh.FingerprintLegacyMD5(key), ssh.FingerprintLegacyMD5(key)}
+	for _, fp := range fingerprints {
+		t.Run("测试指纹校验:"+fp, func(t *testing.T) {
+			callback, err := NewSSHClient("10.0.1.14", "root", WithFingerprint(fp)).hostKeyCallback()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := callback(remote.String(), remote, key); err != nil {
+				t.Errorf("fingerprint want nil but get %v", err)
+			}
+			if err := callback(remote.String(), remote, newTestPublicKey(t)); !errors.Is(err, ErrHostKeyMismatch) {
+				t.Errorf("fingerprint want %v but get %v", ErrHostKeyMismatch, err)
+			}
+		})
+	}
+}
+
+func TestHostKeyServer(t *testing.T) {
+	srv := sshtest.NewServer(sshtest.Output("ok", "", 0))
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), "known_hosts")
+	t.Run("测试首次连接后校验主机密钥", func(t *testing.T) {
+		if _, err := newTestCli(t, srv, WithTrustOnFirstUse(file)).Run("true"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := newTestCli(t, srv, WithKnownHosts(file)).Run("true"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("测试主机指纹不匹配", func(t *testing.T) {
+		_, err := newTestCli(t, srv, WithFingerprint(ssh.FingerprintSHA256(newTestPublicKey(t)))).Run("true")
+		var hostKeyErr *HostKeyError
+		if !errors.As(err, &hostKeyErr) || !errors.Is(err, ErrConnectFail) {
+			t.Errorf("Run() want HostKeyError but get %v", err)
+		}
+	})
+}
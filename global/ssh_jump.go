@@ -0,0 +1,88 @@
+package global
+
+import (
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// WithJumpHost 通过跳板机连接，多个跳板机按顺序依次连接，和 ssh -J 一致
+// 第一个跳板机使用自己的连接，多个目标主机可以共用；后面的跳板机为每个目标主机单独通过前一个跳板机连接
+// 不会修改 jumps 的配置，同一个跳板机可以用于不同的跳板机链
+// @param jumps 跳板机
+func WithJumpHost(jumps ...*Cli) SSHOption {
+	jumps = append([]*Cli(nil), jumps...)
+	return func(c *Cli) {
+		c.jumps = jumps
+	}
+}
+
+// dial 连接 ssh 服务端，配置跳板机时通过跳板机转发
+func (c *Cli) dial(addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	if len(c.jumps) == 0 {
+		return ssh.Dial("tcp", addr, config)
+	}
+	client, err := c.jumps[0].Client()
+	if err != nil {
+		return nil, err
+	}
+	// 中间的跳板机连接由目标主机的连接持有，目标主机连接关闭后依次关闭
+	var hops []*ssh.Client
+	closeHops := func() {
+		for i := len(hops) - 1; i >= 0; i-- {
+			hops[i].Close()
+		}
+	}
+	for _, jump := range c.jumps[1:] {
+		prev := client
+		client, err = jump.handshake(func(addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+			return dialThrough(prev, addr, config)
+		})
+		if err != nil {
+			closeHops()
+			return nil, err
+		}
+		hops = append(hops, client)
+	}
+	target, err := dialThrough(client, addr, config)
+	if err != nil {
+		closeHops()
+		return nil, err
+	}
+	if len(hops) > 0 {
+		go func() {
+			target.Wait()
+			closeHops()
+		}()
+	}
+	return target, nil
+}
+
+// dialThrough 通过已有的 ssh 连接转发到 addr 并握手
+func dialThrough(client *ssh.Client, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := client.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	// 通过跳板机转发的连接不支持超时设置，握手超时时关闭连接
+	timer := time.AfterFunc(dialTimeout(config), func() {
+		conn.Close()
+	})
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if !timer.Stop() && err == nil {
+		sshConn.Close()
+		err = ErrDialTimeout
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
+
+func dialTimeout(config *ssh.ClientConfig) time.Duration {
+	if config.Timeout > 0 {
+		return config.Timeout
+	}
+	return 10 * time.Second
+}
@@ -0,0 +1,406 @@
+package global
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricsOptions 指标采集配置
+type MetricsOptions struct {
+	Interval   time.Duration //CPU使用率采样间隔,默认1秒
+	Mounts     []string      //采集的挂载点,为空时采集所有挂载点
+	Interfaces []string      //采集的网卡,为空时采集所有网卡
+}
+
+// HostMetrics 主机指标
+type HostMetrics struct {
+	Memory      MemoryMetrics
+	CPU         CPUMetrics
+	Load        LoadMetrics
+	Uptime      time.Duration
+	Disks       []DiskMetrics
+	Networks    []NetMetrics
+	Thermals    []ThermalMetrics
+	CollectedAt time.Time
+}
+
+// MemoryMetrics 内存，单位字节
+type MemoryMetrics struct {
+	Total       uint64
+	Free        uint64
+	Available   uint64 //可用内存,没有 MemAvailable 时为 Free+Buffers+Cached
+	Buffers     uint64
+	Cached      uint64
+	SwapTotal   uint64
+	SwapFree    uint64
+	UsedPercent float64
+}
+
+// CPUMetrics CPU使用率，百分比
+type CPUMetrics struct {
+	Usage float64   //总使用率
+	Cores []float64 //每个核心的使用率
+}
+
+// LoadMetrics 系统负载
+type LoadMetrics struct {
+	Load1   float64
+	Load5   float64
+	Load15  float64
+	Running int //正在运行的进程数
+	Total   int //进程总数
+}
+
+// DiskMetrics 磁盘，单位字节
+type DiskMetrics struct {
+	Filesystem  string
+	Mount       string
+	Total       uint64
+	Used        uint64
+	Available   uint64
+	UsedPercent float64
+}
+
+// NetMetrics 网卡流量计数
+type NetMetrics struct {
+	Interface string
+	RxBytes   uint64
+	RxPackets uint64
+	RxErrors  uint64
+	RxDropped uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxErrors  uint64
+	TxDropped uint64
+}
+
+// ThermalMetrics 温度，单位摄氏度
+type ThermalMetrics struct {
+	Zone string
+	Type string
+	Temp float64
+}
+
+// 采集脚本输出的分隔标记
+const metricsSection = "@@helper-metrics:"
+
+// CollectMetrics 一次执行采集内存、CPU、负载、运行时间、磁盘、网卡和温度
+// @param opts 采集配置
+func (c *Cli) CollectMetrics(ctx context.Context, opts MetricsOptions) (*HostMetrics, error) {
+	result, err := c.RunContext(ctx, metricsScript(opts), WithEnv("LC_ALL", "C"))
+	if err != nil {
+		return nil, err
+	}
+	return parseMetrics(result.Stdout, opts)
+}
+
+// metricsScript 采集脚本，兼容 busybox
+func metricsScript(opts MetricsOptions) string {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	mounts := make([]string, 0, len(opts.Mounts))
+	for _, m := range opts.Mounts {
+		mounts = append(mounts, shellQuote(m))
+	}
+	section := func(name string) string {
+		return "echo '" + metricsSection + name + "'; "
+	}
+	return section("meminfo") + "cat /proc/meminfo; " +
+		section("stat1") + "grep '^cpu' /proc/stat; " +
+		"sleep " + strconv.FormatFloat(interval.Seconds(), 'f', -1, 64) + "; " +
+		section("stat2") + "grep '^cpu' /proc/stat; " +
+		section("loadavg") + "cat /proc/loadavg; " +
+		section("uptime") + "cat /proc/uptime; " +
+		section("df") + "df -Pk " + strings.Join(mounts, " ") + " 2>/dev/null; " +
+		section("netdev") + "cat /proc/net/dev; " +
+		section("thermal") + "for z in /sys/class/thermal/thermal_zone*; do " +
+		"[ -r \"$z/temp\" ] && echo \"${z##*/} $(cat \"$z/temp\") $(cat \"$z/type\" 2>/dev/null)\"; done; true"
+}
+
+// parseMetrics 解析采集脚本输出
+func parseMetrics(out string, opts MetricsOptions) (*HostMetrics, error) {
+	sections := map[string]string{}
+	var name string
+	var b strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, metricsSection) {
+			if name != "" {
+				sections[name] = b.String()
+			}
+			name = strings.TrimPrefix(line, metricsSection)
+			b.Reset()
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	if name != "" {
+		sections[name] = b.String()
+	}
+
+	metrics := &HostMetrics{CollectedAt: time.Now()}
+	var err error
+	if metrics.Memory, err = parseMeminfo(sections["meminfo"]); err != nil {
+		return nil, err
+	}
+	if metrics.CPU, err = parseCPUUsage(sections["stat1"], sections["stat2"]); err != nil {
+		return nil, err
+	}
+	if metrics.Load, err = parseLoadavg(sections["loadavg"]); err != nil {
+		return nil, err
+	}
+	if metrics.Uptime, err = parseUptime(sections["uptime"]); err != nil {
+		return nil, err
+	}
+	metrics.Disks = parseDf(sections["df"])
+	metrics.Networks = parseNetDev(sections["netdev"], opts.Interfaces)
+	metrics.Thermals = parseThermal(sections["thermal"])
+	return metrics, nil
+}
+
+// parseMeminfo 解析 /proc/meminfo
+func parseMeminfo(s string) (MemoryMetrics, error) {
+	var m MemoryMetrics
+	values := map[string]uint64{}
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			v *= 1024
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = v
+	}
+	total, ok := values["MemTotal"]
+	if !ok || total == 0 {
+		return m, fmt.Errorf("meminfo: MemTotal not found")
+	}
+	m.Total = total
+	m.Free = values["MemFree"]
+	m.Buffers = values["Buffers"]
+	m.Cached = values["Cached"]
+	m.SwapTotal = values["SwapTotal"]
+	m.SwapFree = values["SwapFree"]
+	if available, ok := values["MemAvailable"]; ok {
+		m.Available = available
+	} else {
+		m.Available = m.Free + m.Buffers + m.Cached
+	}
+	if m.Available <= m.Total {
+		m.UsedPercent = float64(m.Total-m.Available) / float64(m.Total) * 100
+	}
+	return m, nil
+}
+
+// cpuTimes /proc/stat 中的 cpu 时间
+type cpuTimes struct {
+	idle  uint64
+	total uint64
+}
+
+func parseCPUTimes(s string) (map[string]cpuTimes, []string) {
+	times := map[string]cpuTimes{}
+	var names []string
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		var t cpuTimes
+		for i, f := range fields[1:] {
+			// guest 和 guest_nice 已经包含在 user 和 nice 中
+			if i >= 8 {
+				break
+			}
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				continue
+			}
+			t.total += v
+			// idle 和 iowait
+			if i == 3 || i == 4 {
+				t.idle += v
+			}
+		}
+		times[fields[0]] = t
+		names = append(names, fields[0])
+	}
+	return times, names
+}
+
+// parseCPUUsage 根据两次 /proc/stat 计算使用率
+func parseCPUUsage(first, second string) (CPUMetrics, error) {
+	var m CPUMetrics
+	before, _ := parseCPUTimes(first)
+	after, names := parseCPUTimes(second)
+	usage := func(name string) float64 {
+		b, a := before[name], after[name]
+		if a.total <= b.total {
+			return 0
+		}
+		var idle float64
+		if a.idle > b.idle {
+			idle = float64(a.idle - b.idle)
+		}
+		return (float64(a.total-b.total) - idle) / float64(a.total-b.total) * 100
+	}
+	if _, ok := after["cpu"]; !ok {
+		return m, fmt.Errorf("stat: cpu not found")
+	}
+	m.Usage = usage("cpu")
+	for _, name := range names {
+		if name != "cpu" {
+			m.Cores = append(m.Cores, usage(name))
+		}
+	}
+	return m, nil
+}
+
+// parseLoadavg 解析 /proc/loadavg，例如 0.20 0.18 0.12 1/80 11206
+func parseLoadavg(s string) (LoadMetrics, error) {
+	var m LoadMetrics
+	fields := strings.Fields(s)
+	if len(fields) < 4 {
+		return m, fmt.Errorf("loadavg: %q", s)
+	}
+	var err error
+	if m.Load1, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return m, fmt.Errorf("loadavg %w", err)
+	}
+	if m.Load5, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return m, fmt.Errorf("loadavg %w", err)
+	}
+	if m.Load15, err = strconv.ParseFloat(fields[2], 64); err != nil {
+		return m, fmt.Errorf("loadavg %w", err)
+	}
+	if procs := strings.SplitN(fields[3], "/", 2); len(procs) == 2 {
+		m.Running, _ = strconv.Atoi(procs[0])
+		m.Total, _ = strconv.Atoi(procs[1])
+	}
+	return m, nil
+}
+
+// parseUptime 解析 /proc/uptime，例如 350735.47 234388.90
+func parseUptime(s string) (time.Duration, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("uptime: %q", s)
+	}
+	sec, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("uptime %w", err)
+	}
+	return time.Duration(sec * float64(time.Second)), nil
+}
+
+// parseDf 解析 df -Pk
+func parseDf(s string) []DiskMetrics {
+	var disks []DiskMetrics
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "Filesystem" {
+			continue
+		}
+		total, err1 := strconv.ParseUint(fields[1], 10, 64)
+		used, err2 := strconv.ParseUint(fields[2], 10, 64)
+		avail, err3 := strconv.ParseUint(fields[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		d := DiskMetrics{
+			Filesystem: fields[0],
+			Mount:      strings.Join(fields[5:], " "),
+			Total:      total * 1024,
+			Used:       used * 1024,
+			Available:  avail * 1024,
+		}
+		if used+avail > 0 {
+			d.UsedPercent = float64(used) / float64(used+avail) * 100
+		}
+		disks = append(disks, d)
+	}
+	return disks
+}
+
+// parseNetDev 解析 /proc/net/dev
+// @param interfaces 只返回这些网卡,为空时返回所有网卡
+func parseNetDev(s string, interfaces []string) []NetMetrics {
+	var nets []NetMetrics
+	for _, line := range strings.Split(s, "\n") {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		name := strings.TrimSpace(line[:i])
+		if len(interfaces) > 0 && !containsString(interfaces, name) {
+			continue
+		}
+		fields := strings.Fields(line[i+1:])
+		if len(fields) < 16 {
+			continue
+		}
+		v := make([]uint64, 16)
+		for j := range v {
+			v[j], _ = strconv.ParseUint(fields[j], 10, 64)
+		}
+		nets = append(nets, NetMetrics{
+			Interface: name,
+			RxBytes:   v[0],
+			RxPackets: v[1],
+			RxErrors:  v[2],
+			RxDropped: v[3],
+			TxBytes:   v[8],
+			TxPackets: v[9],
+			TxErrors:  v[10],
+			TxDropped: v[11],
+		})
+	}
+	return nets
+}
+
+// parseThermal 解析 thermal_zone0 45000 x86_pkg_temp
+func parseThermal(s string) []ThermalMetrics {
+	var thermals []ThermalMetrics
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		temp, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		// 内核以千分之一摄氏度为单位，部分设备直接返回摄氏度
+		if temp > 1000 || temp < -1000 {
+			temp /= 1000
+		}
+		t := ThermalMetrics{Zone: fields[0], Temp: temp}
+		if len(fields) > 2 {
+			t.Type = strings.Join(fields[2:], " ")
+		}
+		thermals = append(thermals, t)
+	}
+	return thermals
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package global
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/snowlyg/helper/global/sshtest"
+)
+
+var metricsOutput = `@@helper-metrics:meminfo
+MemTotal:        2048000 kB
+MemFree:          512000 kB
+MemAvailable:    1024000 kB
+Buffers:           10240 kB
+Cached:           204800 kB
+SwapTotal:             0 kB
+SwapFree:              0 kB
+@@helper-metrics:stat1
+cpu  100 0 100 800 0 0 0 0 0 0
+cpu0 50 0 50 400 0 0 0 0 0 0
+cpu1 50 0 50 400 0 0 0 0 0 0
+@@helper-metrics:stat2
+cpu  150 0 150 900 0 0 0 0 0 0
+cpu0 100 0 100 400 0 0 0 0 0 0
+cpu1 50 0 50 500 0 0 0 0 0 0
+@@helper-metrics:loadavg
+0.20 0.18 0.12 1/80 11206
+@@helper-metrics:uptime
+350735.47 234388.90
+@@helper-metrics:df
+Filesystem     1024-blocks     Used Available Capacity Mounted on
+/dev/sda1         10000000  2500000   7500000      25% /
+/dev/mmcblk0p1     1000000   500000    500000      50% /sdcard
+@@helper-metrics:netdev
+Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    1000      10    0    0    0     0          0         0     1000      10    0    0    0     0       0          0
+ wlan0: 2000000    2000    1    2    0     0          0         0   300000     300    3    4    0     0       0          0
+@@helper-metrics:thermal
+thermal_zone0 45000 x86_pkg_temp
+thermal_zone1 52 soc
+`
+
+func TestParseMetrics(t *testing.T) {
+	t.Run("测试解析主机指标", func(t *testing.T) {
+		m, err := parseMetrics(metricsOutput, MetricsOptions{Interfaces: []string{"wlan0"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Memory.Total != 2048000*1024 || m.Memory.Available != 1024000*1024 || m.Memory.UsedPercent != 50 {
+			t.Errorf("memory get %+v", m.Memory)
+		}
+		if m.CPU.Usage != 50 || len(m.CPU.Cores) != 2 || m.CPU.Cores[0] != 100 || m.CPU.Cores[1] != 0 {
+			t.Errorf("cpu get %+v", m.CPU)
+		}
+		if m.Load.Load1 != 0.2 || m.Load.Load15 != 0.12 || m.Load.Running != 1 || m.Load.Total != 80 {
+			t.Errorf("load get %+v", m.Load)
+		}
+		if m.Uptime.Truncate(time.Second) != 350735*time.Second {
+			t.Errorf("uptime get %v", m.Uptime)
+		}
+		if len(m.Disks) != 2 || m.Disks[1].Mount != "/sdcard" || m.Disks[1].UsedPercent != 50 || m.Disks[0].Total != 10000000*1024 {
+			t.Errorf("disks get %+v", m.Disks)
+		}
+		if len(m.Networks) != 1 || m.Networks[0].Interface != "wlan0" || m.Networks[0].RxBytes != 2000000 || m.Networks[0].TxDropped != 4 {
+			t.Errorf("networks get %+v", m.Networks)
+		}
+		if len(m.Thermals) != 2 || m.Thermals[0].Temp != 45 || m.Thermals[1].Temp != 52 || m.Thermals[0].Type != "x86_pkg_temp" {
+			t.Errorf("thermals get %+v", m.Thermals)
+		}
+	})
+	t.Run("测试没有 MemAvailable", func(t *testing.T) {
+		m, err := parseMeminfo("MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 100 kB\nCached: 300 kB\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Available != 500*1024 || math.Abs(m.UsedPercent-50) > 0.001 {
+			t.Errorf("meminfo get %+v", m)
+		}
+	})
+	t.Run("测试错误输出", func(t *testing.T) {
+		if _, err := parseMetrics("not metrics", MetricsOptions{}); err == nil {
+			t.Error("parseMetrics() want error but get nil")
+		}
+	})
+}
+
+func TestCollectMetrics(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("CollectMetrics 需要 /proc 文件系统")
+	}
+	srv := sshtest.NewServer(nil)
+	defer srv.Close()
+	t.Run("测试采集本机指标", func(t *testing.T) {
+		metrics, err := newTestCli(t, srv).CollectMetrics(context.Background(), MetricsOptions{Interval: 100 * time.Millisecond, Mounts: []string{"/"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if metrics.Memory.Total == 0 || len(metrics.CPU.Cores) == 0 || metrics.Uptime <= 0 {
+			t.Errorf("CollectMetrics() get %+v", metrics)
+		}
+		if len(metrics.Disks) != 1 {
+			t.Errorf("CollectMetrics() disks want %d but get %d", 1, len(metrics.Disks))
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package global
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/snowlyg/helper/i18n"
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrSudoAuthFail = i18n.NewError("ssh.sudo_auth_fail")
+
+// PtyOptions 终端配置
+type PtyOptions struct {
+	Term   string            //终端类型,默认 xterm-256color
+	Width  int               //列数,默认80
+	Height int               //行数,默认24
+	Modes  ssh.TerminalModes //终端模式,默认开启回显
+}
+
+// PtySession 带终端的会话，可以执行需要 tty 的命令或者交互式 shell
+type PtySession struct {
+	*ssh.Session
+}
+
+// Resize 修改终端窗口大小
+func (p *PtySession) Resize(width, height int) error {
+	return p.WindowChange(height, width)
+}
+
+// NewPty 新建带终端的会话，调用方需要设置输入输出并调用 Shell 或者 Start
+func (c *Cli) NewPty(opts PtyOptions) (*PtySession, error) {
+	if opts.Term == "" {
+		opts.Term = "xterm-256color"
+	}
+	if opts.Width <= 0 {
+		opts.Width = 80
+	}
+	if opts.Height <= 0 {
+		opts.Height = 24
+	}
+	if opts.Modes == nil {
+		opts.Modes = ssh.TerminalModes{
+			ssh.ECHO:          1,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+	}
+	session, err := c.newSession()
+	if err != nil {
+		return nil, err
+	}
+	if err := session.RequestPty(opts.Term, opts.Height, opts.Width, opts.Modes); err != nil {
+		session.Close()
+		return nil, &sshError{kind: ErrNewSessionFail, err: err}
+	}
+	return &PtySession{Session: session}, nil
+}
+
+// Shell 交互式 shell，连接到本地输入输出，直到远程 shell 退出
+// 本地终端需要调用方设置为 raw 模式，窗口大小变化时调用 PtySession.Resize
+// @param onStart shell 启动后回调，可以为 nil
+func (c *Cli) Shell(stdin io.Reader, stdout, stderr io.Writer, opts PtyOptions, onStart ...func(p *PtySession)) error {
+	p, err := c.NewPty(opts)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	p.Stdin = stdin
+	p.Stdout = stdout
+	p.Stderr = stderr
+	if err := p.Session.Shell(); err != nil {
+		return &sshError{kind: ErrRunCommandFail, err: err}
+	}
+	for _, fn := range onStart {
+		fn(p)
+	}
+	return c.exitError(&RunResult{Cmd: "shell"}, p.Wait())
+}
+
+// RunSudo 使用 sudo 执行命令，自动使用 Password 回答 sudo 密码提示
+// 终端关闭回显，密码不会出现在输出中，输出中的标准输出和标准错误合并
+// @param cmd shell脚本命令
+func (c *Cli) RunSudo(cmd string) (*RunResult, error) {
+	marker := make([]byte, 8)
+	rand.Read(marker)
+	prompt := "[sudo-" + hex.EncodeToString(marker) + "]"
+	p, err := c.NewPty(PtyOptions{Modes: ssh.TerminalModes{
+		ssh.ECHO:          0,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+	stdin, err := p.StdinPipe()
+	if err != nil {
+		return nil, &sshError{kind: ErrNewSessionFail, err: err}
+	}
+	w := &sudoWriter{prompt: []byte(prompt), password: c.Password, stdin: stdin}
+	p.Stdout = w
+	p.Stderr = w
+
+	result := &RunResult{Cmd: cmd}
+	start := time.Now()
+	err = p.Run(fmt.Sprintf("sudo -S -p %s sh -c %s", shellQuote(prompt), shellQuote(cmd)))
+	result.Duration = time.Since(start)
+	result.Stdout = w.String()
+	err = c.exitError(result, err)
+	if err != nil && w.Prompts() > 1 {
+		if c.Debug {
+			log.Println(ErrSudoAuthFail.Error())
+		}
+		return result, &sshError{kind: ErrSudoAuthFail, err: err}
+	}
+	return result, err
+}
+
+// sudoWriter 识别 sudo 密码提示并回答，输出中去掉提示和回显的密码
+type sudoWriter struct {
+	mu       sync.Mutex
+	prompt   []byte
+	password string
+	stdin    io.WriteCloser
+	pending  []byte
+	out      bytes.Buffer
+	prompts  int
+	echoes   []int //提示在 out 中的位置,提示后面的一行可能是回显的密码
+}
+
+func (w *sudoWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.Index(w.pending, w.prompt)
+		if i < 0 {
+			break
+		}
+		w.out.Write(w.pending[:i])
+		w.pending = w.pending[i+len(w.prompt):]
+		w.echoes = append(w.echoes, w.out.Len())
+		w.prompts++
+		if w.prompts == 1 {
+			io.WriteString(w.stdin, w.password+"\n")
+		} else {
+			// 密码错误时不再重试
+			w.stdin.Close()
+		}
+	}
+	// 保留可能是提示开头的部分
+	if keep := len(w.prompt) - 1; len(w.pending) > keep {
+		w.out.Write(w.pending[:len(w.pending)-keep])
+		w.pending = w.pending[len(w.pending)-keep:]
+	}
+	return len(p), nil
+}
+
+// String 输出内容，统一换行符，只去掉提示后面和密码相同的一行，不修改命令输出中的其他内容
+func (w *sudoWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := w.out.String() + string(w.pending)
+	for i := len(w.echoes) - 1; i >= 0; i-- {
+		at := w.echoes[i]
+		line := out[at:]
+		end := strings.IndexByte(line, '\n')
+		if end < 0 {
+			end = len(line)
+		} else {
+			end++
+		}
+		if strings.TrimRight(line[:end], "\r\n") == w.password {
+			out = out[:at] + out[at+end:]
+		}
+	}
+	return strings.ReplaceAll(out, "\r\n", "\n")
+}
+
+// Prompts sudo 密码提示次数
+func (w *sudoWriter) Prompts() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.prompts
+}
@@ -0,0 +1,135 @@
+package global
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/snowlyg/helper/global/sshtest"
+)
+
+type nopWriteCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *nopWriteCloser) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestSudoWriter(t *testing.T) {
+	t.Run("测试回答 sudo 密码提示", func(t *testing.T) {
+		stdin := &nopWriteCloser{}
+		w := &sudoWriter{prompt: []byte("[sudo-abc]"), password: "secret", stdin: stdin}
+		w.Write([]byte("[su"))
+		w.Write([]byte("do-abc]\r\nroot\r\n"))
+		if stdin.String() != "secret\n" {
+			t.Errorf("sudoWriter stdin want %q but get %q", "secret\n", stdin.String())
+		}
+		if got := w.String(); got != "\nroot\n" {
+			t.Errorf("sudoWriter output want %q but get %q", "\nroot\n", got)
+		}
+		if w.Prompts() != 1 || stdin.closed {
+			t.Errorf("sudoWriter prompts want 1 but get %d", w.Prompts())
+		}
+	})
+	t.Run("测试 sudo 密码错误", func(t *testing.T) {
+		stdin := &nopWriteCloser{}
+		w := &sudoWriter{prompt: []byte("[sudo-abc]"), password: "secret", stdin: stdin}
+		w.Write([]byte("[sudo-abc]secret\r\nSorry, try again.\r\n[sudo-abc]"))
+		if !stdin.closed || w.Prompts() != 2 {
+			t.Errorf("sudoWriter want closed stdin after 2 prompts but get %d", w.Prompts())
+		}
+		if got := w.String(); got != "Sorry, try again.\n" {
+			t.Errorf("sudoWriter output want password hidden but get %q", got)
+		}
+	})
+	t.Run("测试输出中包含密码", func(t *testing.T) {
+		stdin := &nopWriteCloser{}
+		w := &sudoWriter{prompt: []byte("[sudo-abc]"), password: "1", stdin: stdin}
+		w.Write([]byte("[sudo-abc]\r\nuid=0(root) 1 1\r\n1\r\n"))
+		if got := w.String(); got != "\nuid=0(root) 1 1\n1\n" {
+			t.Errorf("sudoWriter output want unchanged but get %q", got)
+		}
+	})
+}
+
+// fakeSudo 模拟 sudo -S -p prompt sh -c cmd，只接受测试密码
+func fakeSudo(s *sshtest.Session) int {
+	if _, ok := s.Pty(); !ok {
+		io.WriteString(s.Stderr, "sudo: no tty present\n")
+		return 1
+	}
+	args := strings.SplitN(s.Command, "'", 3)
+	if len(args) < 3 {
+		return 1
+	}
+	prompt := args[1]
+	r := bufio.NewReader(s.Stdin)
+	for i := 0; i < 3; i++ {
+		io.WriteString(s.Stdout, prompt)
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return 1
+		}
+		if strings.TrimSpace(line) == sshtest.DefaultPassword {
+			io.WriteString(s.Stdout, "\r\nroot\r\n")
+			return 0
+		}
+		io.WriteString(s.Stdout, "\r\nSorry, try again.\r\n")
+	}
+	return 1
+}
+
+func TestRunSudo(t *testing.T) {
+	mux := sshtest.NewMux()
+	mux.HandlePrefix("sudo ", fakeSudo)
+	srv := sshtest.NewServer(mux.Serve)
+	defer srv.Close()
+	t.Run("测试 sudo 执行命令", func(t *testing.T) {
+		result, err := newTestCli(t, srv).RunSudo("whoami")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(result.Stdout) != "root" {
+			t.Errorf("RunSudo() want %s but get %q", "root", result.Stdout)
+		}
+	})
+	t.Run("测试 sudo 密码错误", func(t *testing.T) {
+		srv.AddUser("guest", "guest")
+		cli := NewSSHClient(srv.Host(), "guest", WithPort(srv.Port()), WithPassword("guest"))
+		defer cli.Close()
+		result, err := cli.RunSudo("whoami")
+		if !errors.Is(err, ErrSudoAuthFail) {
+			t.Errorf("RunSudo() want ErrSudoAuthFail but get %v", err)
+		}
+		if result != nil && strings.Contains(result.Stdout, "[sudo-") {
+			t.Errorf("RunSudo() output contains prompt %q", result.Stdout)
+		}
+	})
+}
+
+func TestShell(t *testing.T) {
+	srv := sshtest.NewServer(nil)
+	defer srv.Close()
+	t.Run("测试交互式 shell", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		var size [2]int
+		err := newTestCli(t, srv).Shell(strings.NewReader("echo $((1+2))\nexit 0\n"), &stdout, &stderr, PtyOptions{Width: 100, Height: 40}, func(p *PtySession) {
+			size[0], size[1] = 100, 40
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(stdout.String(), "3") {
+			t.Errorf("Shell() output want 3 but get %q", stdout.String())
+		}
+		if size != [2]int{100, 40} {
+			t.Errorf("Shell() onStart not called")
+		}
+	})
+}
@@ -0,0 +1,659 @@
+package global
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snowlyg/helper/dir"
+	"github.com/snowlyg/helper/i18n"
+)
+
+var (
+	ErrTransferFail     = i18n.NewError("ssh.transfer_fail")
+	ErrChecksumMismatch = i18n.NewError("ssh.checksum_mismatch")
+	ErrScpProtocol      = i18n.NewError("ssh.scp_protocol")
+	ErrScpFileName      = i18n.NewError("ssh.scp_file_name")
+	ErrScpAck           = i18n.NewError("ssh.scp_ack")
+)
+
+// TransferProgress 传输进度回调
+// @param name 文件路径
+// @param written 已传输字节数
+// @param total 文件大小
+type TransferProgress func(name string, written, total int64)
+
+// TransferOption 文件传输配置项
+type TransferOption func(*transferConfig)
+
+type transferConfig struct {
+	progress TransferProgress
+	checksum bool
+	preserve bool
+}
+
+func newTransferConfig(opts ...TransferOption) *transferConfig {
+	cfg := &transferConfig{preserve: true}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithProgress 传输进度回调
+func WithProgress(progress TransferProgress) TransferOption {
+	return func(cfg *transferConfig) {
+		cfg.progress = progress
+	}
+}
+
+// WithChecksum 传输完成后使用 md5 校验文件
+func WithChecksum() TransferOption {
+	return func(cfg *transferConfig) {
+		cfg.checksum = true
+	}
+}
+
+// WithPreserve 是否保留文件权限和修改时间，默认保留
+func WithPreserve(preserve bool) TransferOption {
+	return func(cfg *transferConfig) {
+		cfg.preserve = preserve
+	}
+}
+
+// Upload 通过 scp 上传文件
+// @param localPath 本地文件路径
+// @param remotePath 远程文件路径
+func (c *Cli) Upload(localPath, remotePath string, opts ...TransferOption) error {
+	cfg := newTransferConfig(opts...)
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrTransferFail, i18n.Message("ssh.scp_is_dir", localPath))
+	}
+	err = c.scpSend(scpCommand("-t", cfg.preserve, false)+shellQuote(remotePath), cfg, func(s *scpSender) error {
+		return s.sendFile(localPath, path.Base(remotePath), info)
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.checksum {
+		return c.verifyFile(localPath, remotePath)
+	}
+	return nil
+}
+
+// UploadContent 通过 scp 上传内容到远程文件
+// @param content 文件内容
+// @param remotePath 远程文件路径
+// @param mode 文件权限
+func (c *Cli) UploadContent(content []byte, remotePath string, mode os.FileMode, opts ...TransferOption) error {
+	cfg := newTransferConfig(opts...)
+	err := c.scpSend(scpCommand("-t", false, false)+shellQuote(remotePath), cfg, func(s *scpSender) error {
+		return s.sendContent(bytes.NewReader(content), path.Base(remotePath), mode, int64(len(content)), remotePath)
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.checksum {
+		sum, err := dir.Md5Byte(content)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+		}
+		return c.verifyChecksum(sum, remotePath, remotePath)
+	}
+	return nil
+}
+
+// Download 通过 scp 下载文件
+// @param remotePath 远程文件路径
+// @param localPath 本地文件路径，为已存在的目录时保存到该目录下
+func (c *Cli) Download(remotePath, localPath string, opts ...TransferOption) error {
+	cfg := newTransferConfig(opts...)
+	if dir.IsExist(localPath) && !dir.IsFile(localPath) {
+		localPath = filepath.Join(localPath, path.Base(remotePath))
+	}
+	err := c.scpReceive(scpCommand("-f", cfg.preserve, false)+shellQuote(remotePath), localPath, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.checksum {
+		return c.verifyFile(localPath, remotePath)
+	}
+	return nil
+}
+
+// UploadDir 通过 scp 上传目录，localDir 下的内容上传到 remoteDir 下
+// @param localDir 本地目录
+// @param remoteDir 远程目录，不存在时自动创建
+func (c *Cli) UploadDir(localDir, remoteDir string, opts ...TransferOption) error {
+	cfg := newTransferConfig(opts...)
+	entries, err := os.ReadDir(localDir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	if _, err := c.Exec("mkdir -p " + shellQuote(remoteDir)); err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	err = c.scpSend(scpCommand("-d -t", cfg.preserve, true)+shellQuote(remoteDir), cfg, func(s *scpSender) error {
+		return s.sendEntries(localDir, entries)
+	})
+	if err != nil {
+		return err
+	}
+	if cfg.checksum {
+		return c.verifyDir(localDir, remoteDir)
+	}
+	return nil
+}
+
+// DownloadDir 通过 scp 下载目录，remoteDir 下的内容下载到 localDir 下
+// @param remoteDir 远程目录
+// @param localDir 本地目录，不存在时自动创建
+func (c *Cli) DownloadDir(remoteDir, localDir string, opts ...TransferOption) error {
+	cfg := newTransferConfig(opts...)
+	err := c.scpReceive(scpCommand("-f", cfg.preserve, true)+shellQuote(remoteDir), localDir, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.checksum {
+		return c.verifyDir(localDir, remoteDir)
+	}
+	return nil
+}
+
+// SyncDir 同步本地目录到远程目录，只上传远程不存在或者 md5 不一致的文件
+// 返回上传的文件相对路径
+// @param localDir 本地目录
+// @param remoteDir 远程目录，不存在时自动创建
+func (c *Cli) SyncDir(localDir, remoteDir string, opts ...TransferOption) ([]string, error) {
+	locals, err := localChecksums(localDir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	remotes, err := c.remoteChecksums(remoteDir, true)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	parents := map[string]bool{}
+	for name, sum := range locals {
+		if remotes[name] != sum {
+			changed = append(changed, name)
+			parents[path.Dir(path.Join(remoteDir, name))] = true
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	sort.Strings(changed)
+	dirs := make([]string, 0, len(parents))
+	for p := range parents {
+		dirs = append(dirs, shellQuote(p))
+	}
+	sort.Strings(dirs)
+	if _, err := c.Exec("mkdir -p " + strings.Join(dirs, " ")); err != nil {
+		return nil, err
+	}
+	for i, name := range changed {
+		if err := c.Upload(filepath.Join(localDir, filepath.FromSlash(name)), path.Join(remoteDir, name), opts...); err != nil {
+			return changed[:i], err
+		}
+	}
+	return changed, nil
+}
+
+// scpCommand 远程 scp 命令
+func scpCommand(mode string, preserve, recursive bool) string {
+	cmd := "scp " + mode
+	if preserve {
+		cmd += " -p"
+	}
+	if recursive {
+		cmd += " -r"
+	}
+	return cmd + " "
+}
+
+// scpSender scp 发送端
+type scpSender struct {
+	w   io.Writer
+	r   *bufio.Reader
+	cfg *transferConfig
+}
+
+// scpSend 执行远程 scp -t 并发送文件
+func (c *Cli) scpSend(cmd string, cfg *transferConfig, send func(s *scpSender) error) error {
+	session, err := c.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	w, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	var stderr lockedBuffer
+	session.Stderr = &stderr
+	if err := session.Start(cmd); err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	s := &scpSender{w: w, r: bufio.NewReader(r), cfg: cfg}
+	err = s.readAck()
+	if err == nil {
+		err = send(s)
+	}
+	w.Close()
+	waitErr := session.Wait()
+	if err == nil && waitErr != nil {
+		err = waitErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v %s", ErrTransferFail, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (s *scpSender) readAck() error {
+	return readScpAck(s.r)
+}
+
+// sendTimes 发送修改时间
+func (s *scpSender) sendTimes(info os.FileInfo) error {
+	if !s.cfg.preserve {
+		return nil
+	}
+	mtime := info.ModTime().Unix()
+	if _, err := fmt.Fprintf(s.w, "T%d 0 %d 0\n", mtime, mtime); err != nil {
+		return err
+	}
+	return s.readAck()
+}
+
+// sendFile 发送单个文件
+func (s *scpSender) sendFile(localPath, name string, info os.FileInfo) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := s.sendTimes(info); err != nil {
+		return err
+	}
+	return s.sendContent(f, name, info.Mode().Perm(), info.Size(), localPath)
+}
+
+// sendContent 发送文件内容
+// @param progressName 进度回调中的文件名
+func (s *scpSender) sendContent(r io.Reader, name string, mode os.FileMode, size int64, progressName string) error {
+	if _, err := fmt.Fprintf(s.w, "C%04o %d %s\n", mode.Perm(), size, name); err != nil {
+		return err
+	}
+	if err := s.readAck(); err != nil {
+		return err
+	}
+	pw := &progressWriter{w: s.w, name: progressName, total: size, progress: s.cfg.progress}
+	if _, err := io.CopyN(pw, r, size); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte{0}); err != nil {
+		return err
+	}
+	return s.readAck()
+}
+
+// sendEntries 递归发送目录下的文件和目录，忽略其他类型文件
+func (s *scpSender) sendEntries(localDir string, entries []os.DirEntry) error {
+	for _, entry := range entries {
+		p := filepath.Join(localDir, entry.Name())
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if err := s.sendDir(p, info); err != nil {
+				return err
+			}
+		} else if info.Mode().IsRegular() {
+			if err := s.sendFile(p, entry.Name(), info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sendDir 发送目录
+func (s *scpSender) sendDir(localDir string, info os.FileInfo) error {
+	entries, err := os.ReadDir(localDir)
+	if err != nil {
+		return err
+	}
+	if err := s.sendTimes(info); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "D%04o 0 %s\n", info.Mode().Perm(), info.Name()); err != nil {
+		return err
+	}
+	if err := s.readAck(); err != nil {
+		return err
+	}
+	if err := s.sendEntries(localDir, entries); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(s.w, "E\n"); err != nil {
+		return err
+	}
+	return s.readAck()
+}
+
+// scpReceive 执行远程 scp -f 并接收文件
+// @param localPath 第一个文件或者目录保存的本地路径
+func (c *Cli) scpReceive(cmd, localPath string, cfg *transferConfig) error {
+	session, err := c.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	w, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	var stderr lockedBuffer
+	session.Stderr = &stderr
+	if err := session.Start(cmd); err != nil {
+		return fmt.Errorf("%w: %v", ErrTransferFail, err)
+	}
+	err = receiveScp(bufio.NewReader(r), w, localPath, cfg)
+	w.Close()
+	waitErr := session.Wait()
+	if err == nil && waitErr != nil {
+		err = waitErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v %s", ErrTransferFail, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// receiveScp scp 接收端协议
+func receiveScp(r *bufio.Reader, w io.Writer, localPath string, cfg *transferConfig) error {
+	ack := func() error {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	var dirs []string
+	var dirTimes []*time.Time
+	var mtime *time.Time
+	received := false
+	if err := ack(); err != nil {
+		return err
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			if !received {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch line[0] {
+		case 1, 2:
+			return errors.New(strings.TrimSpace(line[1:]))
+		case 'T':
+			fields := strings.Fields(line[1:])
+			if len(fields) != 4 {
+				return fmt.Errorf("%w: %q", ErrScpProtocol, line)
+			}
+			sec, err := strconv.ParseInt(fields[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("%w: %q", ErrScpProtocol, line)
+			}
+			t := time.Unix(sec, 0)
+			mtime = &t
+		case 'C', 'D':
+			mode, size, name, err := parseScpHeader(line)
+			if err != nil {
+				return err
+			}
+			target := localPath
+			if len(dirs) > 0 {
+				target = filepath.Join(dirs[len(dirs)-1], name)
+			}
+			received = true
+			if line[0] == 'D' {
+				if err := os.MkdirAll(target, 0755); err != nil {
+					return err
+				}
+				if cfg.preserve {
+					os.Chmod(target, mode)
+				}
+				dirs = append(dirs, target)
+				dirTimes = append(dirTimes, mtime)
+				mtime = nil
+				break
+			}
+			if err := ack(); err != nil {
+				return err
+			}
+			if err := receiveFile(r, target, mode, size, cfg); err != nil {
+				return err
+			}
+			if err := readScpAck(r); err != nil {
+				return err
+			}
+			if cfg.preserve && mtime != nil {
+				os.Chtimes(target, *mtime, *mtime)
+			}
+			mtime = nil
+		case 'E':
+			if len(dirs) == 0 {
+				return fmt.Errorf("%w: %q", ErrScpProtocol, line)
+			}
+			if t := dirTimes[len(dirTimes)-1]; cfg.preserve && t != nil {
+				os.Chtimes(dirs[len(dirs)-1], *t, *t)
+			}
+			dirs = dirs[:len(dirs)-1]
+			dirTimes = dirTimes[:len(dirTimes)-1]
+		default:
+			return fmt.Errorf("%w: %q", ErrScpProtocol, line)
+		}
+		if err := ack(); err != nil {
+			return err
+		}
+	}
+}
+
+// receiveFile 接收文件内容
+func receiveFile(r io.Reader, target string, mode os.FileMode, size int64, cfg *transferConfig) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if cfg.preserve {
+		perm = mode
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pw := &progressWriter{w: f, name: target, total: size, progress: cfg.progress}
+	if _, err := io.CopyN(pw, r, size); err != nil {
+		return err
+	}
+	if cfg.preserve {
+		return f.Chmod(mode)
+	}
+	return nil
+}
+
+// parseScpHeader 解析 C0644 12 name 或者 D0755 0 name
+func parseScpHeader(line string) (os.FileMode, int64, string, error) {
+	parts := strings.SplitN(strings.TrimSuffix(line[1:], "\n"), " ", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", fmt.Errorf("%w: %q", ErrScpProtocol, line)
+	}
+	mode, err := strconv.ParseUint(parts[0], 8, 32)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("%w: %q", ErrScpProtocol, line)
+	}
+	size, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || size < 0 {
+		return 0, 0, "", fmt.Errorf("%w: %q", ErrScpProtocol, line)
+	}
+	name := parts[2]
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return 0, 0, "", fmt.Errorf("%w: %q", ErrScpFileName, name)
+	}
+	return os.FileMode(mode).Perm(), size, name, nil
+}
+
+// readScpAck 读取 scp 应答，0 成功，1 警告，2 错误
+func readScpAck(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch b {
+	case 0:
+		return nil
+	case 1, 2:
+		msg, _ := r.ReadString('\n')
+		return errors.New(strings.TrimSpace(msg))
+	default:
+		return fmt.Errorf("%w: %d", ErrScpAck, b)
+	}
+}
+
+// progressWriter 统计传输进度
+type progressWriter struct {
+	w        io.Writer
+	name     string
+	total    int64
+	written  int64
+	progress TransferProgress
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.progress != nil {
+		p.progress(p.name, p.written, p.total)
+	}
+	return n, err
+}
+
+// verifyFile md5 校验本地文件和远程文件
+func (c *Cli) verifyFile(localPath, remotePath string) error {
+	local, err := dir.MD5(localPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+	}
+	return c.verifyChecksum(local, localPath, remotePath)
+}
+
+// verifyChecksum md5 校验远程文件
+// @param sum 本地 md5
+// @param name 错误信息中的本地文件名
+func (c *Cli) verifyChecksum(sum, name, remotePath string) error {
+	result, err := c.Exec("md5sum " + shellQuote(remotePath))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+	}
+	fields := strings.Fields(result.Stdout)
+	if len(fields) == 0 || fields[0] != sum {
+		return fmt.Errorf("%w: %s %s", ErrChecksumMismatch, name, remotePath)
+	}
+	return nil
+}
+
+// verifyDir md5 校验本地目录和远程目录下的所有文件
+func (c *Cli) verifyDir(localDir, remoteDir string) error {
+	locals, err := localChecksums(localDir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+	}
+	remotes, err := c.remoteChecksums(remoteDir, false)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrChecksumMismatch, err)
+	}
+	if len(locals) != len(remotes) {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, i18n.Message("ssh.scp_file_count", len(locals), len(remotes)))
+	}
+	for name, sum := range locals {
+		if remotes[name] != sum {
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, name)
+		}
+	}
+	return nil
+}
+
+// localChecksums 本地目录下所有文件的 md5，key 为 / 分隔的相对路径
+func localChecksums(localDir string) (map[string]string, error) {
+	sums := map[string]string{}
+	err := filepath.Walk(localDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		sum, err := dir.MD5(p)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	return sums, err
+}
+
+// remoteChecksums 远程目录下所有文件的 md5，key 为 / 分隔的相对路径
+// @param create 目录不存在时是否创建
+func (c *Cli) remoteChecksums(remoteDir string, create bool) (map[string]string, error) {
+	cmd := "cd " + shellQuote(remoteDir) + " && find . -type f -exec md5sum {} \\;"
+	if create {
+		cmd = "mkdir -p " + shellQuote(remoteDir) + " && " + cmd
+	}
+	result, err := c.Exec(cmd)
+	if err != nil {
+		return nil, err
+	}
+	sums := map[string]string{}
+	for _, line := range strings.Split(result.Stdout, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimLeft(fields[1], " *"), "./")
+		sums[name] = fields[0]
+	}
+	return sums, nil
+}
@@ -0,0 +1,187 @@
+package global
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snowlyg/helper/dir"
+	"github.com/snowlyg/helper/global/sshtest"
+)
+
+// scpLoopback 本地模拟 scp 发送端和接收端
+func scpLoopback(t *testing.T, localPath string, cfg *transferConfig, send func(s *scpSender) error) {
+	sinkR, sourceW := io.Pipe()
+	sourceR, sinkW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := receiveScp(bufio.NewReader(sinkR), sinkW, localPath, cfg)
+		sinkW.Close()
+		done <- err
+	}()
+	s := &scpSender{w: sourceW, r: bufio.NewReader(sourceR), cfg: cfg}
+	if err := s.readAck(); err != nil {
+		t.Fatal(err)
+	}
+	if err := send(s); err != nil {
+		t.Fatal(err)
+	}
+	sourceW.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScpTransfer(t *testing.T) {
+	src := t.TempDir()
+	mtime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.Local)
+	if _, err := dir.WriteString(filepath.Join(src, "a.conf"), "a=1\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dir.WriteString(filepath.Join(src, "sub", "b.sh"), "#!/bin/sh\necho b\n"); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(filepath.Join(src, "sub", "b.sh"), 0755)
+	os.Chtimes(filepath.Join(src, "a.conf"), mtime, mtime)
+
+	t.Run("测试 scp 传输文件", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "a.conf")
+		var written int64
+		cfg := newTransferConfig(WithProgress(func(name string, w, total int64) { atomic.StoreInt64(&written, w) }))
+		info, _ := os.Stat(filepath.Join(src, "a.conf"))
+		scpLoopback(t, target, cfg, func(s *scpSender) error {
+			return s.sendFile(filepath.Join(src, "a.conf"), "a.conf", info)
+		})
+		got, err := dir.ReadString(target)
+		if err != nil || got != "a=1\n" {
+			t.Errorf("scp file want %q but get %q %v", "a=1\n", got, err)
+		}
+		if n := atomic.LoadInt64(&written); n != 4 {
+			t.Errorf("scp progress want %d but get %d", 4, n)
+		}
+		if info, _ := os.Stat(target); !info.ModTime().Equal(mtime) {
+			t.Errorf("scp mtime want %v but get %v", mtime, info.ModTime())
+		}
+	})
+
+	t.Run("测试 scp 传输目录", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "dst")
+		info, _ := os.Stat(src)
+		scpLoopback(t, target, newTransferConfig(), func(s *scpSender) error {
+			return s.sendDir(src, info)
+		})
+		locals, err := localChecksums(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		remotes, err := localChecksums(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(locals) != 2 || len(remotes) != 2 || locals["sub/b.sh"] != remotes["sub/b.sh"] {
+			t.Errorf("scp dir want %v but get %v", locals, remotes)
+		}
+		if info, _ := os.Stat(filepath.Join(target, "sub", "b.sh")); info.Mode().Perm() != 0755 {
+			t.Errorf("scp mode want %v but get %v", os.FileMode(0755), info.Mode().Perm())
+		}
+	})
+}
+
+func TestParseScpHeader(t *testing.T) {
+	t.Run("测试 scp 协议头", func(t *testing.T) {
+		mode, size, name, err := parseScpHeader("C0644 12 a b.txt\n")
+		if err != nil || mode != 0644 || size != 12 || name != "a b.txt" {
+			t.Errorf("parseScpHeader() get %v %d %q %v", mode, size, name, err)
+		}
+		for _, line := range []string{"C0644 -1 a\n", "Cxx 1 a\n", "C0644 1\n"} {
+			if _, _, _, err := parseScpHeader(line); !errors.Is(err, ErrScpProtocol) {
+				t.Errorf("parseScpHeader(%q) want %v but get %v", line, ErrScpProtocol, err)
+			}
+		}
+		for _, line := range []string{"C0644 12 ../a\n", "C0644 12 a/b\n"} {
+			if _, _, _, err := parseScpHeader(line); !errors.Is(err, ErrScpFileName) {
+				t.Errorf("parseScpHeader(%q) want %v but get %v", line, ErrScpFileName, err)
+			}
+		}
+	})
+}
+
+func TestCliTransfer(t *testing.T) {
+	srv := sshtest.NewServer(nil)
+	defer srv.Close()
+	cli := newTestCli(t, srv)
+	local, remote := t.TempDir(), t.TempDir()
+	src := filepath.Join(local, "src")
+	files := map[string]string{"a.txt": "hello", "sub/b.txt": "world"}
+	for name, content := range files {
+		if _, err := dir.WriteString(filepath.Join(src, name), content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("测试上传下载文件", func(t *testing.T) {
+		var written int64
+		err := cli.Upload(filepath.Join(src, "a.txt"), filepath.Join(remote, "a.txt"), WithChecksum(), WithProgress(func(name string, n, total int64) {
+			written = n
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written != 5 {
+			t.Errorf("Upload() progress want %d but get %d", 5, written)
+		}
+		dst := filepath.Join(local, "a.download")
+		if err := cli.Download(filepath.Join(remote, "a.txt"), dst, WithChecksum()); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(dst); string(b) != "hello" {
+			t.Errorf("Download() want %s but get %s", "hello", b)
+		}
+	})
+	t.Run("测试下载不存在的文件", func(t *testing.T) {
+		err := cli.Download(filepath.Join(remote, "missing"), filepath.Join(local, "missing"))
+		if !errors.Is(err, ErrTransferFail) {
+			t.Errorf("Download() want ErrTransferFail but get %v", err)
+		}
+	})
+	t.Run("测试上传下载目录", func(t *testing.T) {
+		remoteDir := filepath.Join(remote, "dir")
+		if err := cli.UploadDir(src, remoteDir, WithChecksum()); err != nil {
+			t.Fatal(err)
+		}
+		dst := filepath.Join(local, "dir.download")
+		if err := cli.DownloadDir(remoteDir, dst); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if b, _ := os.ReadFile(filepath.Join(dst, name)); string(b) != content {
+				t.Errorf("DownloadDir() %s want %s but get %s", name, content, b)
+			}
+		}
+	})
+	t.Run("测试同步目录", func(t *testing.T) {
+		remoteDir := filepath.Join(remote, "sync")
+		changed, err := cli.SyncDir(src, remoteDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changed) != 2 {
+			t.Errorf("SyncDir() first sync want %d files but get %v", 2, changed)
+		}
+		if _, err := dir.WriteString(filepath.Join(src, "a.txt"), "changed"); err != nil {
+			t.Fatal(err)
+		}
+		changed, err = cli.SyncDir(src, remoteDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changed) != 1 || filepath.ToSlash(changed[0]) != "a.txt" {
+			t.Errorf("SyncDir() second sync want [a.txt] but get %v", changed)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package global
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var (
+	ErrScriptRender   = i18n.NewError("ssh.script_render")
+	ErrScriptFileName = i18n.NewError("ssh.script_file_name")
+)
+
+// Script 远程脚本，内容为 text/template 模版，上传到远程临时目录后执行，执行完成后删除临时目录
+//
+//	script, _ := NewScript("backup.sh", "tar czf {{quote .dst}} {{quote .src}}")
+//	result, err := cli.RunScript(ctx, script, map[string]interface{}{"src": "/data", "dst": "/tmp/data.tgz"})
+type Script struct {
+	Name        string                 //脚本文件名
+	Interpreter string                 //解释器,为空时有 #! 开头直接执行,否则使用 sh
+	Args        []string               //脚本参数
+	Vars        map[string]interface{} //默认变量,执行时传入的变量优先
+	Files       map[string]string      //辅助文件,远程文件名和本地路径,和脚本上传到同一个目录,文件名不能包含 /
+	TempDir     string                 //远程临时目录的上级目录,默认 $TMPDIR 或者 /tmp
+	KeepTemp    bool                   //执行后保留远程临时目录,用于排查问题
+
+	tmpl *template.Template
+}
+
+// NewScript 解析脚本模版，模版中可以使用 quote 函数转义 shell 参数，缺少变量时渲染失败
+// @param name 脚本文件名
+// @param text 脚本内容
+func NewScript(name, text string) (*Script, error) {
+	tmpl, err := template.New(name).
+		Funcs(template.FuncMap{"quote": shellQuote}).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScriptRender, err)
+	}
+	return &Script{Name: name, Vars: map[string]interface{}{}, Files: map[string]string{}, tmpl: tmpl}, nil
+}
+
+// LoadScript 从本地文件加载脚本模版
+// @param path 本地脚本路径
+func LoadScript(path string) (*Script, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewScript(filepath.Base(path), string(text))
+}
+
+// Render 使用变量渲染脚本
+// @param vars 变量,覆盖默认变量
+func (s *Script) Render(vars map[string]interface{}) (string, error) {
+	data := make(map[string]interface{}, len(s.Vars)+len(vars))
+	for k, v := range s.Vars {
+		data[k] = v
+	}
+	for k, v := range vars {
+		data[k] = v
+	}
+	var b bytes.Buffer
+	if err := s.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrScriptRender, err)
+	}
+	return b.String(), nil
+}
+
+// command 远程执行命令
+// @param content 渲染后的脚本
+func (s *Script) command(content string) string {
+	name := "./" + s.fileName()
+	interpreter := s.Interpreter
+	if interpreter == "" && !strings.HasPrefix(content, "#!") {
+		interpreter = "sh"
+	}
+	cmd := name
+	if interpreter != "" {
+		cmd = interpreter + " " + name
+	}
+	for _, arg := range s.Args {
+		cmd += " " + shellQuote(arg)
+	}
+	return cmd
+}
+
+// fileName 远程脚本文件名
+func (s *Script) fileName() string {
+	name := path.Base(filepath.ToSlash(s.Name))
+	if name == "." || name == "/" || name == "" {
+		return "script"
+	}
+	return name
+}
+
+// fileNames 排序后的辅助文件名，文件名为空、包含路径分隔符或者是 . 和 .. 时返回错误
+func (s *Script) fileNames() ([]string, error) {
+	names := make([]string, 0, len(s.Files))
+	for name := range s.Files {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+			return nil, fmt.Errorf("%w: %q", ErrScriptFileName, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RunScript 渲染脚本并上传到远程临时目录执行，工作目录为临时目录，执行后删除临时目录
+// @param script 脚本
+// @param vars 变量,覆盖脚本默认变量
+// @param opts 执行配置项
+func (c *Cli) RunScript(ctx context.Context, script *Script, vars map[string]interface{}, opts ...RunOption) (*RunResult, error) {
+	content, err := script.Render(vars)
+	if err != nil {
+		return nil, err
+	}
+	names, err := script.fileNames()
+	if err != nil {
+		return nil, err
+	}
+	tempDir := "\"${TMPDIR:-/tmp}\""
+	if script.TempDir != "" {
+		tempDir = shellQuote(script.TempDir)
+	}
+	mktemp, err := c.RunContext(ctx, "mktemp -d "+tempDir+"/helper-script.XXXXXX")
+	if err != nil {
+		return mktemp, err
+	}
+	remoteDir := strings.TrimSpace(mktemp.Stdout)
+	if script.KeepTemp && c.Debug {
+		log.Printf("script %s uploaded to %s", script.Name, remoteDir)
+	}
+	if !script.KeepTemp {
+		defer func() {
+			if _, err := c.Exec("rm -rf " + shellQuote(remoteDir)); err != nil && c.Debug {
+				log.Println(err.Error())
+			}
+		}()
+	}
+
+	if err := c.UploadContent([]byte(content), path.Join(remoteDir, script.fileName()), 0o700); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err := c.Upload(script.Files[name], path.Join(remoteDir, name)); err != nil {
+			return nil, err
+		}
+	}
+	return c.RunContext(ctx, script.command(content), append([]RunOption{WithDir(remoteDir)}, opts...)...)
+}
+
+// ScriptResult 多个客户端执行脚本的结果
+type ScriptResult struct {
+	Cli    *Cli
+	Result *RunResult
+	Err    error
+}
+
+// RunScriptAll 在多个客户端上并发执行同一个脚本，结果顺序和客户端顺序一致
+// @param vars 每个客户端的变量,可以为 nil
+func RunScriptAll(ctx context.Context, clis []*Cli, script *Script, vars func(cli *Cli) map[string]interface{}, opts ...RunOption) []ScriptResult {
+	results := make([]ScriptResult, len(clis))
+	sem := make(chan struct{}, defaultConcurrency)
+	var wg sync.WaitGroup
+	for i, cli := range clis {
+		wg.Add(1)
+		go func(i int, cli *Cli) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			var v map[string]interface{}
+			if vars != nil {
+				v = vars(cli)
+			}
+			result, err := cli.RunScript(ctx, script, v, opts...)
+			results[i] = ScriptResult{Cli: cli, Result: result, Err: err}
+		}(i, cli)
+	}
+	wg.Wait()
+	return results
+}
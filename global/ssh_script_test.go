@@ -0,0 +1,121 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/snowlyg/helper/dir"
+	"github.com/snowlyg/helper/global/sshtest"
+)
+
+func TestScriptRender(t *testing.T) {
+	script, err := NewScript("backup.sh", "tar czf {{quote .dst}} {{.src}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script.Vars["src"] = "/data"
+	t.Run("测试渲染脚本", func(t *testing.T) {
+		got, err := script.Render(map[string]interface{}{"dst": "it's.tgz"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `tar czf 'it'\''s.tgz' /data`; got != want {
+			t.Errorf("Render() want %s but get %s", want, got)
+		}
+	})
+	t.Run("测试缺少变量", func(t *testing.T) {
+		if _, err := script.Render(nil); !errors.Is(err, ErrScriptRender) {
+			t.Errorf("Render() want %v but get %v", ErrScriptRender, err)
+		}
+	})
+	t.Run("测试模版语法错误", func(t *testing.T) {
+		if _, err := NewScript("bad.sh", "echo {{.name"); !errors.Is(err, ErrScriptRender) {
+			t.Errorf("NewScript() want %v but get %v", ErrScriptRender, err)
+		}
+	})
+}
+
+func TestScriptCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  Script
+		content string
+		want    string
+	}{
+		{name: "默认解释器", script: Script{Name: "a.sh"}, content: "echo a", want: "sh ./a.sh"},
+		{name: "shebang", script: Script{Name: "a.py"}, content: "#!/usr/bin/env python3\n", want: "./a.py"},
+		{name: "指定解释器和参数", script: Script{Name: "dir/a.sh", Interpreter: "bash", Args: []string{"a b"}}, content: "echo a", want: "bash ./a.sh 'a b'"},
+	}
+	for _, tt := range tests {
+		t.Run("测试执行命令:"+tt.name, func(t *testing.T) {
+			if got := tt.script.command(tt.content); got != tt.want {
+				t.Errorf("command() want %s but get %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	srv := sshtest.NewServer(nil)
+	defer srv.Close()
+	helper := filepath.Join(t.TempDir(), "lib.sh")
+	if _, err := dir.WriteString(helper, "greet() { echo \"hello $1\"; }\n"); err != nil {
+		t.Fatal(err)
+	}
+	script, err := NewScript("greet.sh", ". ./lib.sh\ngreet {{quote .name}}\necho \"$1\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	script.Files["lib.sh"] = helper
+	script.Args = []string{"arg 1"}
+	script.TempDir = t.TempDir()
+
+	t.Run("测试上传并执行脚本", func(t *testing.T) {
+		result, err := newTestCli(t, srv).RunScript(context.Background(), script, map[string]interface{}{"name": "o'neil"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "hello o'neil\narg 1\n"; result.Stdout != want {
+			t.Errorf("RunScript() want %q but get %q", want, result.Stdout)
+		}
+		if entries, _ := os.ReadDir(script.TempDir); len(entries) != 0 {
+			t.Errorf("RunScript() temp dir not removed %v", entries)
+		}
+	})
+	t.Run("测试多个客户端执行脚本", func(t *testing.T) {
+		other := sshtest.NewServer(nil)
+		defer other.Close()
+		clis := []*Cli{newTestCli(t, srv), newTestCli(t, other)}
+		names := map[*Cli]string{clis[0]: "a", clis[1]: "b"}
+		results := RunScriptAll(context.Background(), clis, script, func(cli *Cli) map[string]interface{} {
+			return map[string]interface{}{"name": names[cli]}
+		})
+		for i, result := range results {
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if want := "hello " + names[clis[i]] + "\narg 1\n"; result.Cli != clis[i] || result.Result.Stdout != want {
+				t.Errorf("RunScriptAll() %d want %q but get %q", i, want, result.Result.Stdout)
+			}
+		}
+	})
+	t.Run("测试辅助文件名不合法", func(t *testing.T) {
+		for _, name := range []string{"", ".", "..", "../x", "a/../../etc/x", `..\x`} {
+			bad, err := NewScript("bad.sh", "true")
+			if err != nil {
+				t.Fatal(err)
+			}
+			bad.Files[name] = helper
+			bad.TempDir = t.TempDir()
+			if _, err := newTestCli(t, srv).RunScript(context.Background(), bad, nil); !errors.Is(err, ErrScriptFileName) {
+				t.Errorf("RunScript(%q) want %v but get %v", name, ErrScriptFileName, err)
+			}
+			if entries, _ := os.ReadDir(bad.TempDir); len(entries) != 0 {
+				t.Errorf("RunScript(%q) temp dir created %v", name, entries)
+			}
+		}
+	})
+}
@@ -0,0 +1,297 @@
+package global
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var (
+	ErrServiceNotFound    = i18n.NewError("ssh.service_not_found")
+	ErrServiceUnsupported = i18n.NewError("ssh.service_unsupported")
+	ErrServiceName        = i18n.NewError("ssh.service_name")
+)
+
+// InitSystem 初始化系统
+type InitSystem string
+
+const (
+	InitSystemd InitSystem = "systemd" //systemd,使用 systemctl 和 journalctl
+	InitSysV    InitSystem = "sysv"    //SysV 或者 busybox init,使用 /etc/init.d 脚本
+	InitUnknown InitSystem = "unknown" //无法识别
+)
+
+// ServiceStatus 服务状态，systemd 从 systemctl show 解析，SysV 只有 LoadState 和 ActiveState
+type ServiceStatus struct {
+	Name          string
+	Description   string
+	LoadState     string    //loaded,not-found
+	ActiveState   string    //active,inactive,failed,activating
+	SubState      string    //running,dead,exited
+	UnitFileState string    //enabled,disabled,static
+	MainPID       int       //主进程号,未运行时为0
+	Restarts      int       //自动重启次数
+	Memory        uint64    //内存使用,字节
+	ActiveSince   time.Time //最近一次启动时间
+	Props         map[string]string
+}
+
+// Active 服务是否运行中
+func (s *ServiceStatus) Active() bool {
+	return s.ActiveState == "active"
+}
+
+// Enabled 服务是否开机启动
+func (s *ServiceStatus) Enabled() bool {
+	return s.UnitFileState == "enabled"
+}
+
+// systemctl show 读取的属性
+var serviceProps = []string{
+	"Id", "Description", "LoadState", "ActiveState", "SubState", "UnitFileState",
+	"MainPID", "NRestarts", "MemoryCurrent", "ActiveEnterTimestamp",
+}
+
+// InitSystem 检测远程主机的初始化系统，结果会缓存
+func (c *Cli) InitSystem(ctx context.Context) (InitSystem, error) {
+	c.mu.Lock()
+	initSys := c.initSystem
+	c.mu.Unlock()
+	if initSys != "" {
+		return initSys, nil
+	}
+	result, err := c.RunContext(ctx, "if [ -d /run/systemd/system ] && command -v systemctl >/dev/null 2>&1; then echo systemd; "+
+		"elif [ -d /etc/init.d ]; then echo sysv; else echo unknown; fi")
+	if err != nil {
+		return InitUnknown, err
+	}
+	initSys = InitSystem(strings.TrimSpace(result.Stdout))
+	switch initSys {
+	case InitSystemd, InitSysV:
+	default:
+		initSys = InitUnknown
+	}
+	c.mu.Lock()
+	c.initSystem = initSys
+	c.mu.Unlock()
+	return initSys, nil
+}
+
+// ServiceStatus 服务状态，服务不存在时返回 ErrServiceNotFound
+// @param name 服务名称,例如 nginx
+func (c *Cli) ServiceStatus(ctx context.Context, name string) (*ServiceStatus, error) {
+	initSys, err := c.serviceInit(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if initSys == InitSystemd {
+		result, err := c.RunContext(ctx, "systemctl show --no-pager --property="+strings.Join(serviceProps, ",")+" -- "+shellQuote(name))
+		if err != nil {
+			return nil, err
+		}
+		status := parseSystemctlShow(result.Stdout)
+		if status.Name == "" {
+			status.Name = name
+		}
+		if status.LoadState == "not-found" {
+			return status, fmt.Errorf("%w: %s", ErrServiceNotFound, name)
+		}
+		return status, nil
+	}
+
+	status := &ServiceStatus{Name: name, LoadState: "loaded", ActiveState: "active", Props: map[string]string{}}
+	if err := c.sysvExists(ctx, name); err != nil {
+		if errors.Is(err, ErrServiceNotFound) {
+			status.LoadState, status.ActiveState = "not-found", "inactive"
+			return status, err
+		}
+		return nil, err
+	}
+	_, err = c.RunContext(ctx, sysvScript(name)+" status")
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		// LSB 规范中 status 非0表示未运行
+		status.ActiveState = "inactive"
+	default:
+		return nil, err
+	}
+	return status, nil
+}
+
+// ServiceStart 启动服务
+func (c *Cli) ServiceStart(ctx context.Context, name string) error {
+	return c.serviceAction(ctx, name, "start")
+}
+
+// ServiceStop 停止服务
+func (c *Cli) ServiceStop(ctx context.Context, name string) error {
+	return c.serviceAction(ctx, name, "stop")
+}
+
+// ServiceRestart 重启服务
+func (c *Cli) ServiceRestart(ctx context.Context, name string) error {
+	return c.serviceAction(ctx, name, "restart")
+}
+
+// ServiceEnable 设置开机启动，SysV 使用 update-rc.d 或者 chkconfig，都不存在时返回 ErrServiceUnsupported
+func (c *Cli) ServiceEnable(ctx context.Context, name string) error {
+	return c.serviceAction(ctx, name, "enable")
+}
+
+// ServiceDisable 取消开机启动
+func (c *Cli) ServiceDisable(ctx context.Context, name string) error {
+	return c.serviceAction(ctx, name, "disable")
+}
+
+// ServiceLogs 服务最近的日志
+// systemd 使用 journalctl，SysV 从 logread 或者 /var/log/messages 中过滤服务名称
+// @param lines 行数,默认100
+func (c *Cli) ServiceLogs(ctx context.Context, name string, lines int) ([]string, error) {
+	initSys, err := c.serviceInit(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if lines <= 0 {
+		lines = 100
+	}
+	n := strconv.Itoa(lines)
+	cmd := "journalctl --no-pager -o short-iso -n " + n + " -u " + shellQuote(name)
+	if initSys == InitSysV {
+		cmd = "{ logread 2>/dev/null || cat /var/log/messages /var/log/syslog 2>/dev/null; } | grep -F -- " + shellQuote(name) + " | tail -n " + n
+	}
+	result, err := c.RunContext(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	var logs []string
+	scanner := bufio.NewScanner(strings.NewReader(result.Stdout))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "-- ") {
+			// journalctl 的 -- No entries -- 等提示
+			continue
+		}
+		logs = append(logs, line)
+	}
+	return logs, nil
+}
+
+// serviceInit 校验服务名称并检测初始化系统
+func (c *Cli) serviceInit(ctx context.Context, name string) (InitSystem, error) {
+	if name == "" || strings.ContainsAny(name, "/\x00") || strings.HasPrefix(name, "-") {
+		return InitUnknown, fmt.Errorf("%w: %q", ErrServiceName, name)
+	}
+	initSys, err := c.InitSystem(ctx)
+	if err != nil {
+		return initSys, err
+	}
+	if initSys == InitUnknown {
+		return initSys, fmt.Errorf("%w: %s", ErrServiceUnsupported, initSys)
+	}
+	return initSys, nil
+}
+
+// serviceAction 执行服务操作
+// @param action start,stop,restart,enable,disable
+func (c *Cli) serviceAction(ctx context.Context, name, action string) error {
+	initSys, err := c.serviceInit(ctx, name)
+	if err != nil {
+		return err
+	}
+	var cmd string
+	switch {
+	case initSys == InitSystemd:
+		cmd = "systemctl " + action + " -- " + shellQuote(name)
+	case action == "enable" || action == "disable":
+		rc := "defaults"
+		chk := "on"
+		if action == "disable" {
+			rc, chk = "remove", "off"
+		}
+		cmd = "if command -v update-rc.d >/dev/null 2>&1; then update-rc.d " + shellQuote(name) + " " + rc + "; " +
+			"elif command -v chkconfig >/dev/null 2>&1; then chkconfig " + shellQuote(name) + " " + chk + "; else exit 127; fi"
+	default:
+		if err := c.sysvExists(ctx, name); err != nil {
+			return err
+		}
+		cmd = sysvScript(name) + " " + action
+	}
+	result, err := c.RunContext(ctx, cmd)
+	if err != nil && initSys == InitSysV && (action == "enable" || action == "disable") && result != nil && result.ExitCode == 127 {
+		return fmt.Errorf("%w: %s %s", ErrServiceUnsupported, initSys, action)
+	}
+	return err
+}
+
+// sysvExists 检查 SysV 服务脚本是否存在，不存在时返回 ErrServiceNotFound
+// 单独检查而不是使用脚本的退出码，脚本自身也可能返回127
+func (c *Cli) sysvExists(ctx context.Context, name string) error {
+	_, err := c.RunContext(ctx, "[ -x "+sysvScript(name)+" ]")
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("%w: %s", ErrServiceNotFound, name)
+	}
+	return err
+}
+
+// sysvScript SysV 服务脚本路径
+func sysvScript(name string) string {
+	return shellQuote("/etc/init.d/" + name)
+}
+
+// parseSystemctlShow 解析 systemctl show 输出，每行为 Key=Value
+func parseSystemctlShow(out string) *ServiceStatus {
+	status := &ServiceStatus{Props: map[string]string{}}
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		status.Props[key] = value
+		switch key {
+		case "Id":
+			status.Name = strings.TrimSuffix(value, ".service")
+		case "Description":
+			status.Description = value
+		case "LoadState":
+			status.LoadState = value
+		case "ActiveState":
+			status.ActiveState = value
+		case "SubState":
+			status.SubState = value
+		case "UnitFileState":
+			status.UnitFileState = value
+		case "MainPID":
+			status.MainPID, _ = strconv.Atoi(value)
+		case "NRestarts":
+			status.Restarts, _ = strconv.Atoi(value)
+		case "MemoryCurrent":
+			// 未开启内存统计时为 [not set] 或者 18446744073709551615
+			if v, err := strconv.ParseUint(value, 10, 64); err == nil && v != 1<<64-1 {
+				status.Memory = v
+			}
+		case "ActiveEnterTimestamp":
+			status.ActiveSince = parseSystemdTime(value)
+		}
+	}
+	return status
+}
+
+// parseSystemdTime 解析 systemd 时间，例如 Mon 2023-05-08 10:00:00 CST，无法解析时返回零值
+func parseSystemdTime(value string) time.Time {
+	for _, layout := range []string{"Mon 2006-01-02 15:04:05 MST", "Mon 2006-01-02 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,170 @@
+package global
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snowlyg/helper/global/sshtest"
+)
+
+var systemctlShow = `Id=nginx.service
+Description=A high performance web server
+LoadState=loaded
+ActiveState=active
+SubState=running
+UnitFileState=enabled
+MainPID=1234
+NRestarts=2
+MemoryCurrent=[not set]
+ActiveEnterTimestamp=Mon 2023-05-08 10:00:00 UTC
+`
+
+func TestParseSystemctlShow(t *testing.T) {
+	t.Run("测试解析 systemctl show", func(t *testing.T) {
+		status := parseSystemctlShow(systemctlShow)
+		want := time.Date(2023, 5, 8, 10, 0, 0, 0, time.UTC)
+		if status.Name != "nginx" || status.MainPID != 1234 || status.Restarts != 2 || status.Memory != 0 || !status.ActiveSince.Equal(want) {
+			t.Errorf("parseSystemctlShow() get %+v", status)
+		}
+		if !status.Active() || !status.Enabled() || status.Props["SubState"] != "running" {
+			t.Errorf("parseSystemctlShow() state get %+v", status)
+		}
+	})
+}
+
+// newInitServer 模拟指定初始化系统的测试服务端
+func newInitServer(t *testing.T, initSys InitSystem) (*sshtest.Server, *sshtest.Mux) {
+	mux := sshtest.NewMux()
+	mux.HandlePrefix("if [ -d /run/systemd/system ]", sshtest.Output(string(initSys)+"\n", "", 0))
+	srv := sshtest.NewServer(mux.Serve)
+	t.Cleanup(srv.Close)
+	return srv, mux
+}
+
+func TestServiceSystemd(t *testing.T) {
+	srv, mux := newInitServer(t, InitSystemd)
+	mux.HandlePrefix("systemctl show ", func(s *sshtest.Session) int {
+		if strings.HasSuffix(s.Command, "'nginx'") {
+			s.Stdout.Write([]byte(systemctlShow))
+		} else {
+			s.Stdout.Write([]byte("Id=missing.service\nLoadState=not-found\nActiveState=inactive\n"))
+		}
+		return 0
+	})
+	mux.HandlePrefix("systemctl ", sshtest.Output("", "", 0))
+	mux.HandlePrefix("journalctl ", sshtest.Output("2023-05-08T10:00:00+0000 host nginx[1]: started\n-- No entries --\n", "", 0))
+	cli := newTestCli(t, srv)
+	ctx := context.Background()
+
+	t.Run("测试检测初始化系统", func(t *testing.T) {
+		initSys, err := cli.InitSystem(ctx)
+		if err != nil || initSys != InitSystemd {
+			t.Errorf("InitSystem() want %s but get %s %v", InitSystemd, initSys, err)
+		}
+	})
+	t.Run("测试服务状态", func(t *testing.T) {
+		status, err := cli.ServiceStatus(ctx, "nginx")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Active() || status.MainPID != 1234 {
+			t.Errorf("ServiceStatus() get %+v", status)
+		}
+		if _, err := cli.ServiceStatus(ctx, "missing"); !errors.Is(err, ErrServiceNotFound) {
+			t.Errorf("ServiceStatus() want %v but get %v", ErrServiceNotFound, err)
+		}
+	})
+	t.Run("测试服务操作", func(t *testing.T) {
+		ops := []func(ctx context.Context, name string) error{cli.ServiceStart, cli.ServiceStop, cli.ServiceRestart, cli.ServiceEnable, cli.ServiceDisable}
+		for _, op := range ops {
+			if err := op(ctx, "nginx"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var got []string
+		for _, cmd := range mux.Commands() {
+			if strings.HasPrefix(cmd, "systemctl ") && !strings.HasPrefix(cmd, "systemctl show") {
+				got = append(got, cmd)
+			}
+		}
+		want := []string{"systemctl start -- 'nginx'", "systemctl stop -- 'nginx'", "systemctl restart -- 'nginx'", "systemctl enable -- 'nginx'", "systemctl disable -- 'nginx'"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("service commands want %v but get %v", want, got)
+		}
+	})
+	t.Run("测试服务日志", func(t *testing.T) {
+		logs, err := cli.ServiceLogs(ctx, "nginx", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(logs) != 1 || !strings.HasSuffix(logs[0], "started") {
+			t.Errorf("ServiceLogs() get %v", logs)
+		}
+	})
+	t.Run("测试服务名称错误", func(t *testing.T) {
+		for _, name := range []string{"", "../nginx", "--all"} {
+			if err := cli.ServiceStart(ctx, name); !errors.Is(err, ErrServiceName) {
+				t.Errorf("ServiceStart(%q) want %v but get %v", name, ErrServiceName, err)
+			}
+		}
+	})
+}
+
+func TestServiceSysV(t *testing.T) {
+	srv, mux := newInitServer(t, InitSysV)
+	for _, name := range []string{"dropbear", "broken"} {
+		mux.Handle("[ -x '/etc/init.d/"+name+"' ]", sshtest.Output("", "", 0))
+	}
+	mux.HandlePrefix("[ -x '/etc/init.d/", sshtest.Output("", "", 1))
+	mux.HandlePrefix("'/etc/init.d/dropbear' ", func(s *sshtest.Session) int {
+		if strings.HasSuffix(s.Command, " status") {
+			return 3
+		}
+		return 0
+	})
+	// 脚本存在，但是依赖的命令不存在
+	mux.HandlePrefix("'/etc/init.d/broken' ", sshtest.Output("", "start-stop-daemon: not found\n", 127))
+	mux.HandlePrefix("if command -v update-rc.d", sshtest.Output("", "", 127))
+	cli := newTestCli(t, srv)
+	ctx := context.Background()
+
+	t.Run("测试服务状态", func(t *testing.T) {
+		status, err := cli.ServiceStatus(ctx, "dropbear")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Active() || status.LoadState != "loaded" {
+			t.Errorf("ServiceStatus() get %+v", status)
+		}
+		if _, err := cli.ServiceStatus(ctx, "missing"); !errors.Is(err, ErrServiceNotFound) {
+			t.Errorf("ServiceStatus() want %v but get %v", ErrServiceNotFound, err)
+		}
+	})
+	t.Run("测试服务脚本返回127", func(t *testing.T) {
+		status, err := cli.ServiceStatus(ctx, "broken")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Active() || status.LoadState != "loaded" {
+			t.Errorf("ServiceStatus() get %+v", status)
+		}
+		if err := cli.ServiceStart(ctx, "broken"); err == nil || errors.Is(err, ErrServiceNotFound) {
+			t.Errorf("ServiceStart() want exit error but get %v", err)
+		}
+	})
+	t.Run("测试服务操作", func(t *testing.T) {
+		if err := cli.ServiceRestart(ctx, "dropbear"); err != nil {
+			t.Error(err)
+		}
+		if err := cli.ServiceStart(ctx, "missing"); !errors.Is(err, ErrServiceNotFound) {
+			t.Errorf("ServiceStart() want %v but get %v", ErrServiceNotFound, err)
+		}
+		if err := cli.ServiceEnable(ctx, "dropbear"); !errors.Is(err, ErrServiceUnsupported) {
+			t.Errorf("ServiceEnable() want %v but get %v", ErrServiceUnsupported, err)
+		}
+	})
+}
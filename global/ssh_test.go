@@ -1,111 +1,182 @@
 package global
 
 import (
-	_ "embed"
-	"log"
-	"os"
+	"errors"
+	"strings"
 	"testing"
+
+	"github.com/snowlyg/helper/global/sshtest"
 )
 
+var testMeminfo = `MemTotal:        2048000 kB
+MemFree:          512000 kB
+MemAvailable:    1024000 kB
+Buffers:          102400 kB
+Cached:           409600 kB
+`
+
+var testDf = `Filesystem      Size  Used Avail Use% Mounted on
+/dev/block/dm-2  24G  8.6G   15G  37% /sdcard
+`
+
+// newTestDevice 模拟设备的测试服务端
+func newTestDevice(t *testing.T) (*sshtest.Server, *sshtest.Mux) {
+	t.Helper()
+	mux := sshtest.NewMux()
+	mux.Handle("cat /proc/meminfo", sshtest.Output(testMeminfo, "", 0))
+	mux.Handle("df /sdcard -h", sshtest.Output(testDf, "", 0))
+	mux.Handle("iw dev wlan0 link | grep -w signal:", sshtest.Output("\tsignal: -52 dBm\n", "", 0))
+	mux.Handle("date +'%Y/%m/%d %T %Z'", sshtest.Output("2023/05/06 10:20:30 CST\n", "", 0))
+	mux.Handle("acpi -t", sshtest.Output("Thermal 0: ok, 45.0 degrees C\nThermal 1: ok, 52.5 degrees C\n", "", 0))
+	srv := sshtest.NewServer(mux.Serve)
+	t.Cleanup(srv.Close)
+	return srv, mux
+}
+
+// newTestCli 连接测试服务端的客户端
+func newTestCli(t *testing.T, srv *sshtest.Server, opts ...SSHOption) *Cli {
+	t.Helper()
+	opts = append([]SSHOption{WithPort(srv.Port()), WithPassword(sshtest.DefaultPassword)}, opts...)
+	cli := NewSSHClient(srv.Host(), sshtest.DefaultUser, opts...)
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
 func Test_NewSSH(t *testing.T) {
-	sshPwd := os.Getenv("sshPwd")
+	srv, _ := newTestDevice(t)
 	t.Run("测试新建ssh链接", func(t *testing.T) {
-		ip := "10.0.1.14"
-		name := "root"
-		sshClient := NewSSH(ip, name, sshPwd, true, 2022)
+		sshClient := NewSSH(srv.Host(), sshtest.DefaultUser, sshtest.DefaultPassword, true, srv.Port())
 		if sshClient == nil {
 			t.Errorf("客户端为空")
 			return
 		}
+		defer sshClient.Close()
+		if _, err := sshClient.Client(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("测试密码错误", func(t *testing.T) {
+		sshClient := NewSSH(srv.Host(), sshtest.DefaultUser, "wrong", false, srv.Port())
+		defer sshClient.Close()
+		if _, err := sshClient.Run("uptime"); !errors.Is(err, ErrConnectFail) {
+			t.Errorf("Run() want ErrConnectFail but get %v", err)
+		}
 	})
 }
+
 func Test_GetMem(t *testing.T) {
-	sshPwd := os.Getenv("sshPwd")
+	srv, _ := newTestDevice(t)
 	t.Run("测试获取设备内存", func(t *testing.T) {
-		ip := "10.0.1.14"
-		name := "root"
-		sshClient := NewSSH(ip, name, sshPwd, true, 2022)
-		if sshClient == nil {
-			t.Errorf("客户端为空")
-			return
-		}
-		mem, err := sshClient.GetMem()
+		mem, err := newTestCli(t, srv).GetMem()
 		if err != nil {
-			t.Errorf(err.Error())
-			return
+			t.Fatal(err)
+		}
+		if mem.Total.String() != "2048000" {
+			t.Errorf("GetMem() total want %s but get %s", "2048000", mem.Total.String())
+		}
+		if mem.Free.String() != "1024000" {
+			t.Errorf("GetMem() free want %s but get %s", "1024000", mem.Free.String())
+		}
+		if mem.FreeRound.String() != "0.5" {
+			t.Errorf("GetMem() free round want %s but get %s", "0.5", mem.FreeRound.String())
 		}
-		log.Println(mem.Total.String())
 	})
 }
+
 func Test_GetDf(t *testing.T) {
-	sshPwd := os.Getenv("sshPwd")
+	srv, _ := newTestDevice(t)
 	t.Run("测试获取设备硬盘使用", func(t *testing.T) {
-		ip := "10.0.1.14"
-		name := "root"
-		sshClient := NewSSH(ip, name, sshPwd, true, 2022)
-		if sshClient == nil {
-			t.Errorf("客户端为空")
-			return
-		}
-		_, err := sshClient.GetDf()
+		df, err := newTestCli(t, srv).GetDf()
 		if err != nil {
-			t.Errorf(err.Error())
-			return
+			t.Fatal(err)
+		}
+		if df != "37%" {
+			t.Errorf("GetDf() want %s but get %s", "37%", df)
 		}
 	})
 }
+
 func Test_GetSignal(t *testing.T) {
-	sshPwd := os.Getenv("sshPwd")
+	srv, _ := newTestDevice(t)
 	t.Run("测试获取设备信号使用", func(t *testing.T) {
-		ip := "10.0.1.14"
-		name := "root"
-		sshClient := NewSSH(ip, name, sshPwd, true, 2022)
-		if sshClient == nil {
-			t.Errorf("客户端为空")
-			return
-		}
-		_, err := sshClient.GetSignal()
+		signal, err := newTestCli(t, srv).GetSignal()
 		if err != nil {
-			t.Errorf(err.Error())
-			return
+			t.Fatal(err)
+		}
+		if signal != "-52 dBm" {
+			t.Errorf("GetSignal() want %s but get %s", "-52 dBm", signal)
 		}
 	})
 }
 
 func Test_GetDatetime(t *testing.T) {
-	sshPwd := os.Getenv("sshPwd")
+	srv, _ := newTestDevice(t)
 	t.Run("测试获取设备时间使用", func(t *testing.T) {
-		ip := "10.0.1.14"
-		name := "root"
-		sshClient := NewSSH(ip, name, sshPwd, true, 2022)
-		if sshClient == nil {
-			t.Errorf("客户端为空")
-			return
-		}
-		_, err := sshClient.GetDatetime()
+		datetime, err := newTestCli(t, srv).GetDatetime()
 		if err != nil {
-			t.Errorf(err.Error())
-			return
+			t.Fatal(err)
+		}
+		if strings.TrimSpace(datetime) != "2023/05/06 10:20:30 CST" {
+			t.Errorf("GetDatetime() get %s", datetime)
 		}
 	})
 }
+
 func Test_GetCpuTemp(t *testing.T) {
-	sshPwd := os.Getenv("sshPwd")
-	t.Run("测试获取设备时间使用", func(t *testing.T) {
-		ip := "10.0.1.14"
-		name := "root"
-		sshClient := NewSSH(ip, name, sshPwd, true, 2022)
-		if sshClient == nil {
-			t.Errorf("客户端为空")
-			return
-		}
-		cpuTemp, err := sshClient.GetCpuTemp()
+	srv, _ := newTestDevice(t)
+	t.Run("测试获取设备CPU温度", func(t *testing.T) {
+		cpuTemp, err := newTestCli(t, srv).GetCpuTemp()
 		if err != nil {
-			t.Errorf(err.Error())
-			return
+			t.Fatal(err)
+		}
+		if cpuTemp != 0.525 {
+			t.Errorf("GetCpuTemp() want %v but get %v", 0.525, cpuTemp)
+		}
+	})
+}
+
+func TestCliRun(t *testing.T) {
+	srv, mux := newTestDevice(t)
+	mux.Handle("exit 3", sshtest.Output("out\n", "err\n", 3))
+	t.Run("测试复用连接", func(t *testing.T) {
+		cli := newTestCli(t, srv)
+		for i := 0; i < 3; i++ {
+			if _, err := cli.Run("acpi -t"); err != nil {
+				t.Fatal(err)
+			}
 		}
-		if cpuTemp <= 0 {
-			t.Errorf("cpu temp is 0")
+		if srv.Connections() != 1 {
+			t.Errorf("Run() connections want %d but get %d", 1, srv.Connections())
+		}
+	})
+	t.Run("测试断线重连", func(t *testing.T) {
+		cli := newTestCli(t, srv)
+		if _, err := cli.Run("acpi -t"); err != nil {
+			t.Fatal(err)
+		}
+		before := srv.Connections()
+		srv.CloseConnections()
+		if _, err := cli.Run("acpi -t"); err != nil {
+			t.Fatal(err)
+		}
+		if srv.Connections() != before+1 {
+			t.Errorf("Run() connections want %d but get %d", before+1, srv.Connections())
+		}
+	})
+	t.Run("测试退出码和标准错误", func(t *testing.T) {
+		result, err := newTestCli(t, srv).Exec("exit 3")
+		var exitErr *ExitError
+		if !errors.As(err, &exitErr) || !errors.Is(err, ErrRunCommandFail) {
+			t.Fatalf("Exec() want ExitError but get %v", err)
+		}
+		if result.ExitCode != 3 || result.Stdout != "out\n" || result.Stderr != "err\n" {
+			t.Errorf("Exec() get %+v", result)
+		}
+	})
+	t.Run("测试命令不存在", func(t *testing.T) {
+		result, _ := newTestCli(t, srv).Exec("reboot now")
+		if result.ExitCode != 127 {
+			t.Errorf("Exec() exit code want %d but get %d", 127, result.ExitCode)
 		}
 	})
 }
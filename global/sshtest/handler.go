@@ -0,0 +1,189 @@
+package sshtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultHandler 默认命令处理，内置 scp 服务端，其他命令使用本机 sh 执行
+var DefaultHandler = SCPHandler(ShellHandler)
+
+// ShellHandler 使用本机 sh 执行命令，没有命令时执行交互式 sh
+// 客户端发送的信号转发给进程，会话关闭时结束进程
+func ShellHandler(s *Session) int {
+	cmd := exec.Command("sh", "-c", s.Command)
+	if s.Command == "" {
+		cmd = exec.Command("sh")
+	}
+	cmd.Env = os.Environ()
+	for k, v := range s.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stdout = s.Stdout
+	cmd.Stderr = s.Stderr
+	setProcessGroup(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		fmt.Fprintln(s.Stderr, err)
+		return 127
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(s.Stderr, err)
+		return 127
+	}
+	// 不等待标准输入，客户端可能一直不关闭
+	go func() {
+		io.Copy(stdin, s.Stdin)
+		stdin.Close()
+	}()
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- cmd.Wait()
+	}()
+	done := s.Done()
+	for {
+		select {
+		case err := <-waitErr:
+			return exitCode(s, err)
+		case sig := <-s.Signals():
+			if sys, ok := signals[sig]; ok {
+				signalProcess(cmd, sys)
+			}
+		case <-done:
+			signalProcess(cmd, os.Kill)
+			done = nil
+		}
+	}
+}
+
+// signals ssh 信号和系统信号对应关系
+var signals = map[ssh.Signal]os.Signal{
+	ssh.SIGABRT: syscall.SIGABRT,
+	ssh.SIGALRM: syscall.SIGALRM,
+	ssh.SIGFPE:  syscall.SIGFPE,
+	ssh.SIGHUP:  syscall.SIGHUP,
+	ssh.SIGILL:  syscall.SIGILL,
+	ssh.SIGINT:  syscall.SIGINT,
+	ssh.SIGKILL: syscall.SIGKILL,
+	ssh.SIGPIPE: syscall.SIGPIPE,
+	ssh.SIGQUIT: syscall.SIGQUIT,
+	ssh.SIGSEGV: syscall.SIGSEGV,
+	ssh.SIGTERM: syscall.SIGTERM,
+}
+
+// exitCode 进程退出码，被信号结束时设置退出信号
+func exitCode(s *Session, err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		fmt.Fprintln(s.Stderr, err)
+		return 127
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		for name, sig := range signals {
+			if sig == status.Signal() {
+				s.SetExitSignal(name)
+			}
+		}
+	}
+	return exitErr.ExitCode()
+}
+
+// Output 返回固定输出的处理函数
+// @param stdout 标准输出
+// @param stderr 标准错误
+// @param code 退出码
+func Output(stdout, stderr string, code int) Handler {
+	return func(s *Session) int {
+		io.WriteString(s.Stdout, stdout)
+		io.WriteString(s.Stderr, stderr)
+		return code
+	}
+}
+
+// Mux 按命令分发处理函数，完整匹配优先，其次匹配最长前缀
+//
+//	mux := sshtest.NewMux()
+//	mux.Handle("cat /proc/meminfo", sshtest.Output(meminfo, "", 0))
+//	mux.HandlePrefix("sudo ", sudoHandler)
+//	srv := sshtest.NewServer(mux.Serve)
+type Mux struct {
+	NotFound Handler //没有匹配时的处理,默认输出 command not found 并返回 127
+
+	mu       sync.RWMutex
+	exact    map[string]Handler
+	prefixes map[string]Handler
+	commands []string
+}
+
+// NewMux 新建命令分发
+func NewMux() *Mux {
+	return &Mux{exact: map[string]Handler{}, prefixes: map[string]Handler{}}
+}
+
+// Handle 注册完整匹配的命令
+func (m *Mux) Handle(cmd string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.exact[cmd] = h
+}
+
+// HandlePrefix 注册前缀匹配的命令
+func (m *Mux) HandlePrefix(prefix string, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prefixes[prefix] = h
+}
+
+// Commands 已经执行的命令，按执行顺序
+func (m *Mux) Commands() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.commands...)
+}
+
+// Serve 分发命令，作为 Handler 使用
+func (m *Mux) Serve(s *Session) int {
+	m.mu.Lock()
+	m.commands = append(m.commands, s.Command)
+	h := m.match(s.Command)
+	m.mu.Unlock()
+	if h == nil {
+		name := s.Command
+		if fields := strings.Fields(name); len(fields) > 0 {
+			name = fields[0]
+		}
+		fmt.Fprintf(s.Stderr, "sh: %s: command not found\n", name)
+		return 127
+	}
+	return h(s)
+}
+
+// match 查找处理函数，调用方需要持有锁
+func (m *Mux) match(cmd string) Handler {
+	if h, ok := m.exact[cmd]; ok {
+		return h
+	}
+	prefixes := make([]string, 0, len(m.prefixes))
+	for prefix := range m.prefixes {
+		if strings.HasPrefix(cmd, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) > 0 {
+		sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+		return m.prefixes[prefixes[0]]
+	}
+	return m.NotFound
+}
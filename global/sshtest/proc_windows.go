@@ -0,0 +1,14 @@
+package sshtest
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup windows 不需要设置进程组
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcess windows 只支持结束进程
+func signalProcess(cmd *exec.Cmd, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
@@ -0,0 +1,360 @@
+package sshtest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SCPHandler 内置 scp 服务端，处理 scp -t 和 scp -f 命令，不依赖本机 scp 程序
+// 支持 -r、-p、-d 参数，其他命令交给 next 处理
+func SCPHandler(next Handler) Handler {
+	return func(s *Session) int {
+		opts, ok := parseSCPCommand(s.Command)
+		if !ok {
+			return next(s)
+		}
+		var err error
+		if opts.sink {
+			err = scpSink(s, opts)
+		} else {
+			err = scpSource(s, opts)
+		}
+		if err != nil {
+			fmt.Fprintf(s.Stderr, "scp: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+}
+
+type scpOptions struct {
+	sink      bool
+	recursive bool
+	preserve  bool
+	targetDir bool
+	path      string
+}
+
+// parseSCPCommand 解析 scp 命令，不是 scp -t 或者 scp -f 时返回 false
+func parseSCPCommand(cmd string) (scpOptions, bool) {
+	var opts scpOptions
+	args, ok := splitArgs(cmd)
+	if !ok || len(args) < 3 || args[0] != "scp" {
+		return opts, false
+	}
+	mode := false
+	for _, arg := range args[1 : len(args)-1] {
+		if !strings.HasPrefix(arg, "-") {
+			return opts, false
+		}
+		for _, f := range arg[1:] {
+			switch f {
+			case 't':
+				opts.sink, mode = true, true
+			case 'f':
+				mode = true
+			case 'r':
+				opts.recursive = true
+			case 'p':
+				opts.preserve = true
+			case 'd':
+				opts.targetDir = true
+			case 'v', 'q':
+			default:
+				return opts, false
+			}
+		}
+	}
+	opts.path = args[len(args)-1]
+	return opts, mode
+}
+
+// splitArgs 按 shell 规则拆分参数，只支持单引号、双引号和反斜杠
+func splitArgs(cmd string) ([]string, bool) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case c == '\'':
+			j := strings.IndexByte(cmd[i+1:], '\'')
+			if j < 0 {
+				return nil, false
+			}
+			cur.WriteString(cmd[i+1 : i+1+j])
+			i += j + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(cmd) && cmd[i] != '"'; i++ {
+				if cmd[i] == '\\' && i+1 < len(cmd) {
+					i++
+				}
+				cur.WriteByte(cmd[i])
+			}
+			if i >= len(cmd) {
+				return nil, false
+			}
+			inArg = true
+		case c == '\\' && i+1 < len(cmd):
+			i++
+			cur.WriteByte(cmd[i])
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		case strings.IndexByte(";&|<>`$", c) >= 0:
+			// 复合命令交给 shell
+			return nil, false
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, true
+}
+
+// scpSink 接收客户端上传的文件
+func scpSink(s *Session, opts scpOptions) error {
+	r := bufio.NewReader(s.Stdin)
+	w := s.Stdout
+	info, statErr := os.Stat(opts.path)
+	isDir := statErr == nil && info.IsDir()
+	if opts.targetDir && !isDir {
+		w.Write([]byte{2})
+		return fmt.Errorf("%s: Not a directory", opts.path)
+	}
+	ack := func() error {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	fail := func(err error) error {
+		fmt.Fprintf(w, "\x01scp: %v\n", err)
+		return err
+	}
+	if err := ack(); err != nil {
+		return err
+	}
+
+	var dirs []string
+	var mtime, atime time.Time
+	var hasTimes bool
+	// target 顶层条目的保存路径
+	target := func(name string) string {
+		if len(dirs) > 0 {
+			return filepath.Join(dirs[len(dirs)-1], name)
+		}
+		if isDir {
+			return filepath.Join(opts.path, name)
+		}
+		return opts.path
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return fail(errors.New("empty protocol line"))
+		}
+		switch line[0] {
+		case 'T':
+			var m, a int64
+			var mu, au int64
+			if _, err := fmt.Sscanf(line[1:], "%d %d %d %d", &m, &mu, &a, &au); err != nil {
+				return fail(fmt.Errorf("invalid times %q", line))
+			}
+			mtime, atime, hasTimes = time.Unix(m, 0), time.Unix(a, 0), true
+			if err := ack(); err != nil {
+				return err
+			}
+		case 'C', 'D':
+			mode, size, name, err := parseHeader(line)
+			if err != nil {
+				return fail(err)
+			}
+			path := target(name)
+			if line[0] == 'D' {
+				if !opts.recursive {
+					return fail(errors.New("received directory without -r"))
+				}
+				if err := os.MkdirAll(path, mode|0o700); err != nil {
+					return fail(err)
+				}
+				if opts.preserve {
+					os.Chmod(path, mode)
+				}
+				dirs = append(dirs, path)
+				hasTimes = false
+				if err := ack(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := ack(); err != nil {
+				return err
+			}
+			if err := receiveFile(r, path, mode, size); err != nil {
+				return fail(err)
+			}
+			if opts.preserve {
+				os.Chmod(path, mode)
+				if hasTimes {
+					os.Chtimes(path, atime, mtime)
+				}
+			}
+			hasTimes = false
+			if status, err := r.ReadByte(); err != nil || status != 0 {
+				return fmt.Errorf("file %s not confirmed", name)
+			}
+			if err := ack(); err != nil {
+				return err
+			}
+		case 'E':
+			if len(dirs) == 0 {
+				return fail(errors.New("unexpected E"))
+			}
+			dirs = dirs[:len(dirs)-1]
+			if err := ack(); err != nil {
+				return err
+			}
+		case 1, 2:
+			return errors.New(line[1:])
+		default:
+			return fail(fmt.Errorf("unexpected protocol line %q", line))
+		}
+	}
+}
+
+// parseHeader 解析 C 和 D 行，格式为 C0644 12 name
+func parseHeader(line string) (os.FileMode, int64, string, error) {
+	parts := strings.SplitN(line[1:], " ", 3)
+	if len(parts) != 3 {
+		return 0, 0, "", fmt.Errorf("invalid header %q", line)
+	}
+	mode, err := strconv.ParseUint(parts[0], 8, 32)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("invalid mode %q", line)
+	}
+	size, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || size < 0 {
+		return 0, 0, "", fmt.Errorf("invalid size %q", line)
+	}
+	name := parts[2]
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return 0, 0, "", fmt.Errorf("invalid name %q", name)
+	}
+	return os.FileMode(mode) & os.ModePerm, size, name, nil
+}
+
+func receiveFile(r io.Reader, path string, mode os.FileMode, size int64) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		// 读取剩余内容，保持协议同步
+		io.CopyN(io.Discard, r, size)
+		return err
+	}
+	_, err = io.CopyN(f, r, size)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// scpSource 发送文件或者目录给客户端
+func scpSource(s *Session, opts scpOptions) error {
+	r := bufio.NewReader(s.Stdin)
+	w := s.Stdout
+	if err := readAck(r); err != nil {
+		return err
+	}
+	info, err := os.Stat(opts.path)
+	if err != nil {
+		fmt.Fprintf(w, "\x01scp: %v\n", err)
+		return err
+	}
+	if info.IsDir() && !opts.recursive {
+		err := fmt.Errorf("%s: not a regular file", opts.path)
+		fmt.Fprintf(w, "\x01scp: %v\n", err)
+		return err
+	}
+	return sendEntry(r, w, opts, opts.path, info)
+}
+
+func sendEntry(r *bufio.Reader, w io.Writer, opts scpOptions, path string, info os.FileInfo) error {
+	if opts.preserve {
+		fmt.Fprintf(w, "T%d 0 %d 0\n", info.ModTime().Unix(), info.ModTime().Unix())
+		if err := readAck(r); err != nil {
+			return err
+		}
+	}
+	if !info.IsDir() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(w, "C%04o %d %s\n", info.Mode().Perm(), info.Size(), info.Name())
+		if err := readAck(r); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(w, f, info.Size()); err != nil {
+			return err
+		}
+		w.Write([]byte{0})
+		return readAck(r)
+	}
+	fmt.Fprintf(w, "D%04o 0 %s\n", info.Mode().Perm(), info.Name())
+	if err := readAck(r); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		child, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !child.IsDir() && !child.Mode().IsRegular() {
+			continue
+		}
+		if err := sendEntry(r, w, opts, filepath.Join(path, entry.Name()), child); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(w, "E\n")
+	return readAck(r)
+}
+
+// readAck 读取客户端应答
+func readAck(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == 0 {
+		return nil
+	}
+	msg, _ := r.ReadString('\n')
+	return errors.New(strings.TrimSpace(msg))
+}
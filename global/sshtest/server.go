@@ -0,0 +1,436 @@
+// Package sshtest 进程内 SSH 测试服务端，用于在本机测试 SSH 客户端，和 net/http/httptest 用法类似
+//
+//	srv := sshtest.NewServer(nil)
+//	defer srv.Close()
+//	cli := global.NewSSH(srv.Host(), "root", "pwd", false, srv.Port())
+package sshtest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 默认账号
+const (
+	DefaultUser     = "root"
+	DefaultPassword = "pwd"
+)
+
+// Server 测试 SSH 服务端
+// 支持密码、公钥和键盘交互认证，exec、shell、pty、env、signal 会话请求，
+// keepalive 和 tcpip-forward 全局请求，以及 direct-tcpip 端口转发
+type Server struct {
+	Listener net.Listener
+	Handler  Handler                        //命令处理,为空时使用 DefaultHandler
+	HostKey  ssh.Signer                     //主机密钥,默认随机生成 ed25519 密钥
+	Users    map[string]string              //用户名和密码,默认 root/pwd
+	Keys     map[string][]ssh.PublicKey     //用户名和授权公钥
+	Debug    bool                           //打印连接错误
+	Config   func(config *ssh.ServerConfig) //启动前修改服务端配置,可以为 nil
+
+	mu       sync.Mutex
+	conns    map[*ssh.ServerConn]struct{}
+	accepted int
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer 新建并启动测试服务端，监听 127.0.0.1 随机端口
+// @param handler 命令处理,为空时使用 DefaultHandler
+func NewServer(handler Handler) *Server {
+	s := NewUnstartedServer(handler)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer 新建测试服务端，修改配置后调用 Start 启动
+// @param handler 命令处理,为空时使用 DefaultHandler
+func NewUnstartedServer(handler Handler) *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("sshtest: failed to listen on a port: %v", err))
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("sshtest: failed to generate host key: %v", err))
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		panic(fmt.Sprintf("sshtest: failed to generate host key: %v", err))
+	}
+	return &Server{
+		Listener: ln,
+		Handler:  handler,
+		HostKey:  signer,
+		Users:    map[string]string{DefaultUser: DefaultPassword},
+		Keys:     map[string][]ssh.PublicKey{},
+		conns:    map[*ssh.ServerConn]struct{}{},
+	}
+}
+
+// Start 启动服务端
+func (s *Server) Start() {
+	if s.Handler == nil {
+		s.Handler = DefaultHandler
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if s.checkPassword(meta.User(), string(password)) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", meta.User())
+		},
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if s.checkKey(meta.User(), key) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown public key for %s", meta.User())
+		},
+		KeyboardInteractiveCallback: func(meta ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := client(meta.User(), "", []string{"Password: "}, []bool{false})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) == 1 && s.checkPassword(meta.User(), answers[0]) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("keyboard-interactive rejected for %s", meta.User())
+		},
+	}
+	config.AddHostKey(s.HostKey)
+	if s.Config != nil {
+		s.Config(config)
+	}
+	s.wg.Add(1)
+	go s.serve(config)
+}
+
+// Close 关闭监听和所有连接，等待会话结束
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.Listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// CloseConnections 断开所有客户端连接，服务端继续监听，用于测试断线重连
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Addr 监听地址,例如 127.0.0.1:2022
+func (s *Server) Addr() string {
+	return s.Listener.Addr().String()
+}
+
+// Host 监听 IP
+func (s *Server) Host() string {
+	return s.Listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port 监听端口
+func (s *Server) Port() int {
+	return s.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// PublicKey 主机公钥
+func (s *Server) PublicKey() ssh.PublicKey {
+	return s.HostKey.PublicKey()
+}
+
+// Connections 已经认证成功的连接总数
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted
+}
+
+// AddUser 添加密码登录用户
+func (s *Server) AddUser(user, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Users[user] = password
+}
+
+// AddKey 添加用户授权公钥
+func (s *Server) AddKey(user string, key ssh.PublicKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Keys[user] = append(s.Keys[user], key)
+}
+
+func (s *Server) checkPassword(user, password string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	want, ok := s.Users[user]
+	return ok && want == password
+}
+
+func (s *Server) checkKey(user string, key ssh.PublicKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.Keys[user] {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) logf(format string, v ...interface{}) {
+	if s.Debug {
+		log.Printf("sshtest: "+format, v...)
+	}
+}
+
+// serve 接收连接
+func (s *Server) serve(config *ssh.ServerConfig) {
+	defer s.wg.Done()
+	for {
+		conn, err := s.Listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleConn(conn, config)
+		}()
+	}
+}
+
+// handleConn 握手并处理连接上的通道和全局请求
+func (s *Server) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		s.logf("handshake: %v", err)
+		conn.Close()
+		return
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		sconn.Close()
+		return
+	}
+	s.conns[sconn] = struct{}{}
+	s.accepted++
+	s.mu.Unlock()
+
+	fwd := &remoteForwards{listeners: map[string]net.Listener{}}
+	defer func() {
+		fwd.closeAll()
+		s.mu.Lock()
+		delete(s.conns, sconn)
+		s.mu.Unlock()
+		sconn.Close()
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.handleGlobalRequests(sconn, reqs, fwd, &wg)
+	}()
+	for newCh := range chans {
+		switch newCh.ChannelType() {
+		case "session":
+			ch, chReqs, err := newCh.Accept()
+			if err != nil {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.handleSession(sconn.User(), ch, chReqs)
+			}()
+		case "direct-tcpip":
+			wg.Add(1)
+			go func(newCh ssh.NewChannel) {
+				defer wg.Done()
+				s.handleDirectTCPIP(newCh)
+			}(newCh)
+		default:
+			newCh.Reject(ssh.UnknownChannelType, "unknown channel type")
+		}
+	}
+	fwd.closeAll()
+	wg.Wait()
+}
+
+// handleGlobalRequests 处理 keepalive 和远程端口转发请求
+func (s *Server) handleGlobalRequests(sconn *ssh.ServerConn, reqs <-chan *ssh.Request, fwd *remoteForwards, wg *sync.WaitGroup) {
+	for req := range reqs {
+		switch req.Type {
+		case "tcpip-forward":
+			var payload struct {
+				Addr string
+				Port uint32
+			}
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			ln, err := net.Listen("tcp", net.JoinHostPort(payload.Addr, strconv.Itoa(int(payload.Port))))
+			if err != nil {
+				s.logf("tcpip-forward: %v", err)
+				req.Reply(false, nil)
+				continue
+			}
+			port := uint32(ln.Addr().(*net.TCPAddr).Port)
+			fwd.add(forwardKey(payload.Addr, port), ln)
+			if payload.Port == 0 {
+				req.Reply(true, ssh.Marshal(struct{ Port uint32 }{port}))
+			} else {
+				req.Reply(true, nil)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.serveRemoteForward(sconn, ln, payload.Addr, port)
+			}()
+		case "cancel-tcpip-forward":
+			var payload struct {
+				Addr string
+				Port uint32
+			}
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(fwd.remove(forwardKey(payload.Addr, payload.Port)), nil)
+		default:
+			// keepalive@openssh.com 等请求
+			if req.WantReply {
+				req.Reply(req.Type == "keepalive@openssh.com", nil)
+			}
+		}
+	}
+}
+
+// serveRemoteForward 远程端口转发，接收连接后打开 forwarded-tcpip 通道
+func (s *Server) serveRemoteForward(sconn *ssh.ServerConn, ln net.Listener, addr string, port uint32) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		origin := conn.RemoteAddr().(*net.TCPAddr)
+		payload := ssh.Marshal(struct {
+			Addr       string
+			Port       uint32
+			OriginAddr string
+			OriginPort uint32
+		}{addr, port, origin.IP.String(), uint32(origin.Port)})
+		go func() {
+			ch, reqs, err := sconn.OpenChannel("forwarded-tcpip", payload)
+			if err != nil {
+				s.logf("forwarded-tcpip: %v", err)
+				conn.Close()
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+			pipe(ch, conn)
+		}()
+	}
+}
+
+// handleDirectTCPIP 本地端口转发，连接目标地址
+func (s *Server) handleDirectTCPIP(newCh ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginAddr string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newCh.ExtraData(), &payload); err != nil {
+		newCh.Reject(ssh.ConnectionFailed, "invalid payload")
+		return
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, reqs, err := newCh.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	pipe(ch, conn)
+}
+
+// remoteForwards 连接上的远程端口转发监听
+type remoteForwards struct {
+	mu        sync.Mutex
+	listeners map[string]net.Listener
+}
+
+func forwardKey(addr string, port uint32) string {
+	return net.JoinHostPort(addr, strconv.Itoa(int(port)))
+}
+
+func (f *remoteForwards) add(key string, ln net.Listener) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listeners[key] = ln
+}
+
+func (f *remoteForwards) remove(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ln, ok := f.listeners[key]
+	if ok {
+		ln.Close()
+		delete(f.listeners, key)
+	}
+	return ok
+}
+
+func (f *remoteForwards) closeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, ln := range f.listeners {
+		ln.Close()
+		delete(f.listeners, key)
+	}
+}
+
+// pipe 双向复制数据，任意一端结束后关闭两端
+func pipe(ch ssh.Channel, conn net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(ch, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, ch)
+		done <- struct{}{}
+	}()
+	<-done
+	ch.Close()
+	conn.Close()
+	<-done
+}
@@ -0,0 +1,184 @@
+package sshtest
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func dialTest(t *testing.T, srv *Server) *ssh.Client {
+	t.Helper()
+	client, err := ssh.Dial("tcp", srv.Addr(), &ssh.ClientConfig{
+		User:            DefaultUser,
+		Auth:            []ssh.AuthMethod{ssh.Password(DefaultPassword)},
+		HostKeyCallback: ssh.FixedHostKey(srv.PublicKey()),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestServer(t *testing.T) {
+	var got *Session
+	var gotPty Pty
+	mux := NewMux()
+	mux.Handle("whoami", Output("root\n", "", 0))
+	mux.HandlePrefix("env ", func(s *Session) int {
+		got = s
+		gotPty, _ = s.Pty()
+		return 0
+	})
+	mux.HandlePrefix("wait", func(s *Session) int {
+		select {
+		case sig := <-s.Signals():
+			s.SetExitSignal(sig)
+		case <-time.After(5 * time.Second):
+		}
+		return 0
+	})
+	srv := NewServer(mux.Serve)
+	defer srv.Close()
+	client := dialTest(t, srv)
+
+	t.Run("测试执行命令", func(t *testing.T) {
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		out, err := session.Output("whoami")
+		if err != nil || string(out) != "root\n" {
+			t.Errorf("Output() want %q but get %q %v", "root\n", out, err)
+		}
+	})
+	t.Run("测试命令不存在", func(t *testing.T) {
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		var exitErr *ssh.ExitError
+		if err := session.Run("reboot now"); !errors.As(err, &exitErr) || exitErr.ExitStatus() != 127 {
+			t.Errorf("Run() want exit status 127 but get %v", err)
+		}
+	})
+	t.Run("测试环境变量和终端", func(t *testing.T) {
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		if err := session.Setenv("LANG", "C"); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.RequestPty("xterm", 24, 80, ssh.TerminalModes{ssh.ECHO: 0}); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.Run("env LANG"); err != nil {
+			t.Fatal(err)
+		}
+		if got.Env["LANG"] != "C" || got.User != DefaultUser {
+			t.Errorf("Session env get %v user %s", got.Env, got.User)
+		}
+		want := Pty{Term: "xterm", Width: 80, Height: 24, Modes: ssh.TerminalModes{ssh.ECHO: 0}}
+		if !reflect.DeepEqual(gotPty, want) {
+			t.Errorf("Session pty want %+v but get %+v", want, gotPty)
+		}
+	})
+	t.Run("测试信号", func(t *testing.T) {
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		if err := session.Start("wait"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if err := session.Signal(ssh.SIGTERM); err != nil {
+			t.Fatal(err)
+		}
+		var exitErr *ssh.ExitError
+		if err := session.Wait(); !errors.As(err, &exitErr) || exitErr.Signal() != "TERM" {
+			t.Errorf("Wait() want signal TERM but get %v", err)
+		}
+	})
+	t.Run("测试 keepalive", func(t *testing.T) {
+		ok, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		if err != nil || !ok {
+			t.Errorf("SendRequest() want true but get %v %v", ok, err)
+		}
+	})
+	if want := []string{"whoami", "reboot now", "env LANG", "wait"}; !reflect.DeepEqual(mux.Commands(), want) {
+		t.Errorf("Mux.Commands() want %v but get %v", want, mux.Commands())
+	}
+}
+
+func TestShellHandler(t *testing.T) {
+	srv := NewServer(nil)
+	defer srv.Close()
+	client := dialTest(t, srv)
+	t.Run("测试本机 shell 执行", func(t *testing.T) {
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		var stderr strings.Builder
+		session.Stdin = strings.NewReader("input")
+		session.Stderr = &stderr
+		out, err := session.Output("cat; echo; echo err >&2; exit 0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "input\n" || stderr.String() != "err\n" {
+			t.Errorf("Output() get %q %q", out, stderr.String())
+		}
+	})
+	t.Run("测试信号结束进程", func(t *testing.T) {
+		session, err := client.NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		if err := session.Start("exec sleep 10"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		session.Signal(ssh.SIGKILL)
+		var exitErr *ssh.ExitError
+		if err := session.Wait(); !errors.As(err, &exitErr) || exitErr.Signal() != "KILL" {
+			t.Errorf("Wait() want signal KILL but get %v", err)
+		}
+	})
+}
+
+func TestParseSCPCommand(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want scpOptions
+		ok   bool
+	}{
+		{cmd: "scp -t -p '/tmp/a b'", want: scpOptions{sink: true, preserve: true, path: "/tmp/a b"}, ok: true},
+		{cmd: "scp -d -t -p -r '/tmp/it'\\''s'", want: scpOptions{sink: true, preserve: true, recursive: true, targetDir: true, path: "/tmp/it's"}, ok: true},
+		{cmd: "scp -rf /tmp/dir", want: scpOptions{recursive: true, path: "/tmp/dir"}, ok: true},
+		{cmd: "scp -r /tmp/dir", ok: false},
+		{cmd: "mkdir -p /tmp && scp -t /tmp", ok: false},
+		{cmd: "cat /proc/meminfo", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run("测试解析 scp 命令:"+tt.cmd, func(t *testing.T) {
+			got, ok := parseSCPCommand(tt.cmd)
+			if ok != tt.ok || (ok && got != tt.want) {
+				t.Errorf("parseSCPCommand() want %+v %v but get %+v %v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
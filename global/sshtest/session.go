@@ -0,0 +1,188 @@
+package sshtest
+
+import (
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Handler 命令处理，返回退出码
+// 处理函数需要在 Session.Done 关闭后尽快返回
+type Handler func(s *Session) int
+
+// Pty 客户端请求的终端
+type Pty struct {
+	Term   string
+	Width  int
+	Height int
+	Modes  ssh.TerminalModes
+}
+
+// Session 会话，包含客户端请求的命令、环境变量和终端
+type Session struct {
+	User    string            //登录用户名
+	Command string            //执行的命令,交互式 shell 为空
+	Env     map[string]string //客户端设置的环境变量
+	Stdin   io.Reader
+	Stdout  io.Writer
+	Stderr  io.Writer //有终端时和 Stdout 相同
+
+	ch         ssh.Channel
+	mu         sync.Mutex
+	pty        *Pty
+	exitSignal string
+	signals    chan ssh.Signal
+	done       chan struct{}
+}
+
+// Pty 客户端请求的终端，没有请求终端时返回 false
+func (s *Session) Pty() (Pty, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pty == nil {
+		return Pty{}, false
+	}
+	return *s.pty, true
+}
+
+// Signals 客户端发送的信号
+func (s *Session) Signals() <-chan ssh.Signal {
+	return s.signals
+}
+
+// Done 客户端关闭会话后关闭
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// CloseStdout 关闭标准输出，客户端读取到 EOF
+func (s *Session) CloseStdout() error {
+	return s.ch.CloseWrite()
+}
+
+// SetExitSignal 设置退出信号，处理函数返回后发送 exit-signal 代替 exit-status
+// @param sig 信号名称,例如 TERM、KILL
+func (s *Session) SetExitSignal(sig ssh.Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exitSignal = string(sig)
+}
+
+// handleSession 处理会话请求，收到 exec 或者 shell 后执行 Handler
+func (s *Server) handleSession(user string, ch ssh.Channel, reqs <-chan *ssh.Request) {
+	sess := &Session{
+		User:    user,
+		Env:     map[string]string{},
+		Stdin:   ch,
+		Stdout:  ch,
+		Stderr:  ch.Stderr(),
+		ch:      ch,
+		signals: make(chan ssh.Signal, 8),
+		done:    make(chan struct{}),
+	}
+	started := false
+	finished := make(chan struct{})
+	defer func() {
+		close(sess.done)
+		if started {
+			<-finished
+		}
+		ch.Close()
+	}()
+	for req := range reqs {
+		ok := false
+		switch req.Type {
+		case "pty-req":
+			var payload struct {
+				Term          string
+				Width, Height uint32
+				PxWidth       uint32
+				PxHeight      uint32
+				Modes         string
+			}
+			if err := ssh.Unmarshal(req.Payload, &payload); err == nil && !started {
+				sess.mu.Lock()
+				sess.pty = &Pty{Term: payload.Term, Width: int(payload.Width), Height: int(payload.Height), Modes: parseModes(payload.Modes)}
+				sess.mu.Unlock()
+				sess.Stderr = ch
+				ok = true
+			}
+		case "window-change":
+			var payload struct {
+				Width, Height     uint32
+				PxWidth, PxHeight uint32
+			}
+			sess.mu.Lock()
+			if err := ssh.Unmarshal(req.Payload, &payload); err == nil && sess.pty != nil {
+				sess.pty.Width, sess.pty.Height = int(payload.Width), int(payload.Height)
+				ok = true
+			}
+			sess.mu.Unlock()
+		case "env":
+			var payload struct{ Name, Value string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err == nil && !started {
+				sess.Env[payload.Name] = payload.Value
+				ok = true
+			}
+		case "signal":
+			var payload struct{ Signal string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err == nil && started {
+				select {
+				case sess.signals <- ssh.Signal(payload.Signal):
+					ok = true
+				default:
+				}
+			}
+		case "exec", "shell":
+			if started {
+				break
+			}
+			if req.Type == "exec" {
+				var payload struct{ Command string }
+				if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+					break
+				}
+				sess.Command = payload.Command
+			}
+			started, ok = true, true
+			go func() {
+				defer close(finished)
+				code := s.Handler(sess)
+				sess.exit(code)
+			}()
+		}
+		if req.WantReply {
+			req.Reply(ok, nil)
+		}
+	}
+}
+
+// exit 发送退出状态并关闭会话
+func (s *Session) exit(code int) {
+	s.mu.Lock()
+	sig := s.exitSignal
+	s.mu.Unlock()
+	s.ch.CloseWrite()
+	if sig != "" {
+		s.ch.SendRequest("exit-signal", false, ssh.Marshal(struct {
+			Signal     string
+			CoreDumped bool
+			Error      string
+			Lang       string
+		}{Signal: sig}))
+	} else {
+		s.ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(code)}))
+	}
+	s.ch.Close()
+}
+
+// parseModes 解析终端模式，格式为 opcode(1字节)+uint32，以 0 结尾
+func parseModes(b string) ssh.TerminalModes {
+	modes := ssh.TerminalModes{}
+	for len(b) >= 5 && b[0] != 0 {
+		modes[b[0]] = uint32(b[1])<<24 | uint32(b[2])<<16 | uint32(b[3])<<8 | uint32(b[4])
+		b = b[5:]
+	}
+	return modes
+}
@@ -7,6 +7,7 @@ require (
 	github.com/go-ping/ping v0.0.0-20211130115550-779d1e919534
 	github.com/shopspring/decimal v0.0.0-20200227202807-02e2044944cc
 	golang.org/x/crypto v0.9.0
+	golang.org/x/net v0.10.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -33,7 +34,6 @@ require (
 	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
 	github.com/ugorji/go/codec v1.2.11 // indirect
 	golang.org/x/arch v0.3.0 // indirect
-	golang.org/x/net v0.10.0 // indirect
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
 	golang.org/x/sys v0.11.1-0.20230817163440-e8190d9965d9 // indirect
 	golang.org/x/text v0.9.0 // indirect
@@ -13,11 +13,34 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/snowlyg/helper/i18n"
 )
 
-var ErrInvalidDataStruct = errors.New("invalid response data struct")
-var ErrBaseAuthConfig = errors.New("base auth config is error")
-var ErrEmptyFileNameField = errors.New("must set field filename")
+var ErrInvalidDataStruct = i18n.NewError("http.invalid_data_struct")
+var ErrBaseAuthConfig = i18n.NewError("http.base_auth_config")
+var ErrEmptyFileNameField = i18n.NewError("http.empty_filename_field")
+
+// decodeError 返回内容解码失败，可以翻译并且兼容 errors.Is 判断解码错误
+type decodeError struct {
+	key    string
+	url    string
+	err    error
+	result []byte
+}
+
+func (e *decodeError) Error() string {
+	return e.Localize(i18n.CurrentLang())
+}
+
+// Localize 指定语言的错误信息
+func (e *decodeError) Localize(lang i18n.Lang) string {
+	return i18n.Sprintf(lang, e.key, e.url, i18n.Localize(e.err, lang), string(e.result))
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
 
 type client struct {
 	config *Config
@@ -149,7 +172,7 @@ func (n *client) Post(sr *ServerResponse, data string) ([]byte, error) {
 	f := n.getFullPath(sr.path)
 	result := n.request("POST", f, sr.BaseAuth(), strings.NewReader(data))
 	if len(result) == 0 {
-		return result, fmt.Errorf("Post %s 没有返回数据", f)
+		return result, errors.New(i18n.Message("http.empty_response", "Post", f))
 	}
 	if !json.Valid(result) || sr.Data == nil {
 		sr.Data = string(result)
@@ -157,7 +180,7 @@ func (n *client) Post(sr *ServerResponse, data string) ([]byte, error) {
 	}
 	err = json.Unmarshal(result, sr.Data)
 	if err != nil {
-		return result, fmt.Errorf("执行解码失败: %s 错误：%w ,结果: %v", f, err, string(result))
+		return result, &decodeError{key: "http.decode_fail", url: f, err: err, result: result}
 	}
 	return result, nil
 }
@@ -212,7 +235,7 @@ func (n *client) Upload(sr *ServerResponse) ([]byte, error) {
 	f := n.getFullPath(sr.path)
 	result := n.request("POST", f, sr.BaseAuth(), body)
 	if len(result) == 0 {
-		return result, fmt.Errorf("Upload %s 没有返回数据", f)
+		return result, errors.New(i18n.Message("http.empty_response", "Upload", f))
 	}
 
 	if !json.Valid(result) || sr.Data == nil {
@@ -222,7 +245,7 @@ func (n *client) Upload(sr *ServerResponse) ([]byte, error) {
 
 	err = json.Unmarshal(result, sr.Data)
 	if err != nil {
-		return result, fmt.Errorf("执行解码失败: %s 错误：%w ,结果: %v", f, err, string(result))
+		return result, &decodeError{key: "http.decode_fail", url: f, err: err, result: result}
 	}
 
 	return result, nil
@@ -237,7 +260,7 @@ func (n *client) Get(sr *ServerResponse) ([]byte, error) {
 	f := n.getFullPath(sr.path)
 	result := n.request("GET", f, sr.BaseAuth(), nil)
 	if len(result) == 0 {
-		return result, fmt.Errorf("Get %s 没有返回数据", f)
+		return result, errors.New(i18n.Message("http.empty_response", "Get", f))
 	}
 	if !json.Valid(result) || sr.Data == nil {
 		sr.Data = string(result)
@@ -245,7 +268,7 @@ func (n *client) Get(sr *ServerResponse) ([]byte, error) {
 	}
 	err = json.Unmarshal(result, sr.Data)
 	if err != nil {
-		return result, fmt.Errorf("执行解码失败: %s 获取服务解析返回内容报错 %w : ,结果:[%s]", f, err, string(result))
+		return result, &decodeError{key: "http.get_decode_fail", url: f, err: err, result: result}
 	}
 	return result, nil
 }
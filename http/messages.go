@@ -0,0 +1,26 @@
+package http
+
+import (
+	"github.com/snowlyg/helper/i18n"
+)
+
+// http 错误信息，使用 i18n.SetLang 切换语言
+// 默认语言的信息和原来的错误信息一致，原来是英文的错误变量在 zh-CN 中也保持英文
+func init() {
+	i18n.Register(i18n.ZhCN, map[string]string{
+		"http.invalid_data_struct":  "invalid response data struct",
+		"http.base_auth_config":     "base auth config is error",
+		"http.empty_filename_field": "must set field filename",
+		"http.empty_response":       "%s %s 没有返回数据",
+		"http.decode_fail":          "执行解码失败: %s 错误：%v ,结果: %v",
+		"http.get_decode_fail":      "执行解码失败: %s 获取服务解析返回内容报错 %v : ,结果:[%s]",
+	})
+	i18n.Register(i18n.EnUS, map[string]string{
+		"http.invalid_data_struct":  "invalid response data struct",
+		"http.base_auth_config":     "base auth config is error",
+		"http.empty_filename_field": "must set field filename",
+		"http.empty_response":       "%s %s returned no data",
+		"http.decode_fail":          "decode failed: %s error: %v, result: %v",
+		"http.get_decode_fail":      "decode failed: %s error: %v, result: [%s]",
+	})
+}
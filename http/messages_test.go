@@ -0,0 +1,47 @@
+package http
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+func TestMessages(t *testing.T) {
+	t.Run("测试错误信息翻译", func(t *testing.T) {
+		sr := NewResponse("/foo")
+		sr.SetBaseAuth("", "")
+		err := NewClient().Check(sr)
+		if err != ErrBaseAuthConfig {
+			t.Fatalf("Check() want %v but get %v", ErrBaseAuthConfig, err)
+		}
+		if got := i18n.Localize(err, i18n.EnUS); got != "base auth config is error" {
+			t.Errorf("Localize(en-US) get %s", got)
+		}
+		if got := err.Error(); got != "base auth config is error" {
+			t.Errorf("Error() get %s", got)
+		}
+	})
+	t.Run("测试解码错误", func(t *testing.T) {
+		cause := errors.New("unexpected end of JSON input")
+		err := error(&decodeError{key: "http.decode_fail", url: "http://127.0.0.1/foo", err: cause, result: []byte("{")})
+		if !errors.Is(err, cause) {
+			t.Errorf("errors.Is() want true")
+		}
+		want := "执行解码失败: http://127.0.0.1/foo 错误：unexpected end of JSON input ,结果: {"
+		if got := err.Error(); got != want {
+			t.Errorf("Error() want %s but get %s", want, got)
+		}
+		want = "decode failed: http://127.0.0.1/foo error: unexpected end of JSON input, result: {"
+		if got := i18n.Localize(err, i18n.EnUS); got != want {
+			t.Errorf("Localize(en-US) want %s but get %s", want, got)
+		}
+	})
+	t.Run("测试英文信息完整", func(t *testing.T) {
+		for _, key := range []string{"http.invalid_data_struct", "http.base_auth_config", "http.empty_filename_field", "http.empty_response", "http.decode_fail", "http.get_decode_fail"} {
+			if !i18n.Default.Has(i18n.ZhCN, key) || !i18n.Default.Has(i18n.EnUS, key) {
+				t.Errorf("message %s is missing", key)
+			}
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error 可以翻译的错误，作为包级别的错误变量使用，兼容 errors.Is
+//
+//	var ErrConnectFail = i18n.NewError("ssh.connect_fail")
+type Error struct {
+	Key     string
+	catalog *Catalog
+}
+
+// NewError 创建使用默认消息目录的错误
+// @param key 消息 key
+func NewError(key string) *Error {
+	return &Error{Key: key}
+}
+
+// NewCatalogError 创建使用指定消息目录的错误
+func NewCatalogError(catalog *Catalog, key string) *Error {
+	return &Error{Key: key, catalog: catalog}
+}
+
+// Error 当前语言的错误信息
+func (e *Error) Error() string {
+	return e.Catalog().Message(e.Key)
+}
+
+// Localize 指定语言的错误信息
+func (e *Error) Localize(lang Lang) string {
+	return e.Catalog().Sprintf(lang, e.Key)
+}
+
+// Catalog 错误使用的消息目录
+func (e *Error) Catalog() *Catalog {
+	if e.catalog == nil {
+		return Default
+	}
+	return e.catalog
+}
+
+// Localizer 可以翻译的错误，包装了 *Error 的错误类型可以实现这个接口
+type Localizer interface {
+	Localize(lang Lang) string
+}
+
+// Localize 翻译错误信息，错误链中第一个 Localizer 的内容替换为指定语言，其他内容不变
+// 例如 fmt.Errorf("%w: nginx", ErrServiceNotFound) 在 en-US 中为 SSH service not found: nginx
+// @param err 错误
+// @param lang 语言
+func Localize(err error, lang Lang) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		l, ok := e.(Localizer)
+		if !ok {
+			continue
+		}
+		if e == err {
+			return l.Localize(lang)
+		}
+		return strings.Replace(msg, e.Error(), l.Localize(lang), 1)
+	}
+	return msg
+}
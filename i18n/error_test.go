@@ -0,0 +1,46 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	c := newTestCatalog()
+	c.Register(ZhCN, map[string]string{"err.fail": "失败"})
+	c.Register(EnUS, map[string]string{"err.fail": "failed"})
+	errFail := NewCatalogError(c, "err.fail")
+
+	t.Run("测试错误信息", func(t *testing.T) {
+		if errFail.Error() != "失败" || errFail.Localize(EnUS) != "failed" {
+			t.Errorf("Error() get %s %s", errFail.Error(), errFail.Localize(EnUS))
+		}
+	})
+	t.Run("测试包装的错误", func(t *testing.T) {
+		err := fmt.Errorf("%w: nginx", errFail)
+		if !errors.Is(err, errFail) {
+			t.Errorf("errors.Is() want true")
+		}
+		if got := Localize(err, EnUS); got != "failed: nginx" {
+			t.Errorf("Localize() get %s", got)
+		}
+		if got := Localize(errFail, EnUS); got != "failed" {
+			t.Errorf("Localize() get %s", got)
+		}
+	})
+	t.Run("测试普通错误", func(t *testing.T) {
+		if got := Localize(errors.New("plain"), EnUS); got != "plain" {
+			t.Errorf("Localize() get %s", got)
+		}
+		if got := Localize(nil, EnUS); got != "" {
+			t.Errorf("Localize() get %s", got)
+		}
+	})
+	t.Run("测试默认消息目录", func(t *testing.T) {
+		Register(ZhCN, map[string]string{"test.default": "默认"})
+		if got := NewError("test.default").Error(); got != "默认" || CurrentLang() != ZhCN {
+			t.Errorf("Error() get %s", got)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package i18n
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Lang 语言
+type Lang string
+
+const (
+	ZhCN Lang = "zh-CN" //简体中文,默认语言
+	EnUS Lang = "en-US" //英文
+)
+
+// Catalog 消息目录，按语言保存消息 key 对应的格式化字符串
+type Catalog struct {
+	mu       sync.RWMutex
+	lang     Lang //当前语言
+	fallback Lang //当前语言没有消息时使用
+	messages map[Lang]map[string]string
+}
+
+// NewCatalog 创建消息目录
+// @param fallback 默认语言,也是当前语言
+func NewCatalog(fallback Lang) *Catalog {
+	return &Catalog{lang: fallback, fallback: fallback, messages: map[Lang]map[string]string{}}
+}
+
+// Register 注册一种语言的消息，已存在的 key 会被覆盖
+// @param lang 语言
+// @param messages 消息 key 和 fmt 格式化字符串
+func (c *Catalog) Register(lang Lang, messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m, ok := c.messages[lang]
+	if !ok {
+		m = make(map[string]string, len(messages))
+		c.messages[lang] = m
+	}
+	for key, format := range messages {
+		m[key] = format
+	}
+}
+
+// SetLang 设置当前语言
+func (c *Catalog) SetLang(lang Lang) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lang = lang
+}
+
+// Lang 当前语言
+func (c *Catalog) Lang() Lang {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lang
+}
+
+// Langs 已注册的语言
+func (c *Catalog) Langs() []Lang {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	langs := make([]Lang, 0, len(c.messages))
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+	return langs
+}
+
+// Has 语言中是否有消息
+func (c *Catalog) Has(lang Lang, key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.messages[lang][key]
+	return ok
+}
+
+// Sprintf 格式化指定语言的消息，没有时使用默认语言，都没有时返回 key
+// @param lang 语言
+// @param key 消息 key
+// @param args 格式化参数
+func (c *Catalog) Sprintf(lang Lang, key string, args ...interface{}) string {
+	c.mu.RLock()
+	format, ok := c.messages[lang][key]
+	if !ok {
+		format, ok = c.messages[c.fallback][key]
+	}
+	c.mu.RUnlock()
+	if !ok {
+		format = key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Message 格式化当前语言的消息
+func (c *Catalog) Message(key string, args ...interface{}) string {
+	return c.Sprintf(c.Lang(), key, args...)
+}
+
+// Default 默认消息目录，各个包的消息都注册到这里
+var Default = NewCatalog(ZhCN)
+
+// Register 向默认消息目录注册消息
+func Register(lang Lang, messages map[string]string) {
+	Default.Register(lang, messages)
+}
+
+// SetLang 设置默认消息目录的当前语言
+func SetLang(lang Lang) {
+	Default.SetLang(lang)
+}
+
+// CurrentLang 默认消息目录的当前语言
+func CurrentLang() Lang {
+	return Default.Lang()
+}
+
+// Sprintf 格式化默认消息目录中指定语言的消息
+func Sprintf(lang Lang, key string, args ...interface{}) string {
+	return Default.Sprintf(lang, key, args...)
+}
+
+// Message 格式化默认消息目录中当前语言的消息
+func Message(key string, args ...interface{}) string {
+	return Default.Message(key, args...)
+}
@@ -0,0 +1,51 @@
+package i18n
+
+import (
+	"testing"
+)
+
+func newTestCatalog() *Catalog {
+	c := NewCatalog(ZhCN)
+	c.Register(ZhCN, map[string]string{"hello": "你好 %s", "only.zh": "只有中文"})
+	c.Register(EnUS, map[string]string{"hello": "hello %s"})
+	return c
+}
+
+func TestCatalog(t *testing.T) {
+	c := newTestCatalog()
+	tests := []struct {
+		name string
+		lang Lang
+		key  string
+		args []interface{}
+		want string
+	}{
+		{name: "中文", lang: ZhCN, key: "hello", args: []interface{}{"世界"}, want: "你好 世界"},
+		{name: "英文", lang: EnUS, key: "hello", args: []interface{}{"world"}, want: "hello world"},
+		{name: "使用默认语言", lang: EnUS, key: "only.zh", want: "只有中文"},
+		{name: "未注册的语言", lang: "ja-JP", key: "hello", args: []interface{}{"世界"}, want: "你好 世界"},
+		{name: "未注册的 key", lang: EnUS, key: "missing", want: "missing"},
+	}
+	for _, tt := range tests {
+		t.Run("测试格式化消息:"+tt.name, func(t *testing.T) {
+			if got := c.Sprintf(tt.lang, tt.key, tt.args...); got != tt.want {
+				t.Errorf("Sprintf() want %s but get %s", tt.want, got)
+			}
+		})
+	}
+	t.Run("测试切换语言", func(t *testing.T) {
+		c.SetLang(EnUS)
+		if got := c.Message("hello", "world"); got != "hello world" || c.Lang() != EnUS {
+			t.Errorf("Message() get %s", got)
+		}
+		if !c.Has(EnUS, "hello") || c.Has(EnUS, "only.zh") || len(c.Langs()) != 2 {
+			t.Errorf("Has() or Langs() get %v", c.Langs())
+		}
+	})
+	t.Run("测试覆盖消息", func(t *testing.T) {
+		c.Register(EnUS, map[string]string{"hello": "hi %s"})
+		if got := c.Sprintf(EnUS, "hello", "world"); got != "hi world" {
+			t.Errorf("Sprintf() get %s", got)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package ipx
+
+import (
+	"net/netip"
+)
+
+// Category 地址分类
+type Category string
+
+const (
+	CategoryInvalid     Category = "invalid"     //不是有效的 IP
+	CategoryUnspecified Category = "unspecified" //0.0.0.0 和 ::
+	CategoryLoopback    Category = "loopback"    //127.0.0.0/8 和 ::1
+	CategoryPrivate     Category = "private"     //RFC1918 私有地址和 IPv6 唯一本地地址 fc00::/7
+	CategoryCGNAT       Category = "cgnat"       //运营商级 NAT 共享地址 100.64.0.0/10
+	CategoryLinkLocal   Category = "link-local"  //169.254.0.0/16 和 fe80::/10
+	CategoryMulticast   Category = "multicast"   //224.0.0.0/4 和 ff00::/8
+	CategoryReserved    Category = "reserved"    //文档、测试、广播等保留地址
+	CategoryPublic      Category = "public"      //公网地址
+)
+
+// categoryPrefixes 按顺序匹配的分类网段
+var categoryPrefixes = []struct {
+	prefix   netip.Prefix
+	category Category
+}{
+	{netip.MustParsePrefix("10.0.0.0/8"), CategoryPrivate},
+	{netip.MustParsePrefix("172.16.0.0/12"), CategoryPrivate},
+	{netip.MustParsePrefix("192.168.0.0/16"), CategoryPrivate},
+	{netip.MustParsePrefix("fc00::/7"), CategoryPrivate},
+	{netip.MustParsePrefix("100.64.0.0/10"), CategoryCGNAT},
+	{netip.MustParsePrefix("0.0.0.0/8"), CategoryReserved},
+	{netip.MustParsePrefix("192.0.0.0/24"), CategoryReserved},
+	{netip.MustParsePrefix("192.0.2.0/24"), CategoryReserved},
+	{netip.MustParsePrefix("198.18.0.0/15"), CategoryReserved},
+	{netip.MustParsePrefix("198.51.100.0/24"), CategoryReserved},
+	{netip.MustParsePrefix("203.0.113.0/24"), CategoryReserved},
+	{netip.MustParsePrefix("240.0.0.0/4"), CategoryReserved},
+	{netip.MustParsePrefix("100::/64"), CategoryReserved},
+	{netip.MustParsePrefix("2001:db8::/32"), CategoryReserved},
+	{netip.MustParsePrefix("3fff::/20"), CategoryReserved},
+}
+
+// Classify 地址分类
+func Classify(addr netip.Addr) Category {
+	if !addr.IsValid() {
+		return CategoryInvalid
+	}
+	addr = addr.Unmap()
+	switch {
+	case addr.IsUnspecified():
+		return CategoryUnspecified
+	case addr.IsLoopback():
+		return CategoryLoopback
+	case addr.IsLinkLocalUnicast():
+		return CategoryLinkLocal
+	case addr.IsMulticast():
+		return CategoryMulticast
+	}
+	for _, c := range categoryPrefixes {
+		if c.prefix.Contains(addr) {
+			return c.category
+		}
+	}
+	return CategoryPublic
+}
+
+// ClassifyString 地址分类，格式错误时返回 CategoryInvalid
+func ClassifyString(ip string) Category {
+	addr, err := ParseAddr(ip)
+	if err != nil {
+		return CategoryInvalid
+	}
+	return Classify(addr)
+}
+
+// IsPrivate 是否是 RFC1918 私有地址或者 IPv6 唯一本地地址
+func IsPrivate(addr netip.Addr) bool {
+	return Classify(addr) == CategoryPrivate
+}
+
+// IsIntranet 是否是内网地址，包括私有地址和 100.64.0.0/10 共享地址
+func IsIntranet(addr netip.Addr) bool {
+	category := Classify(addr)
+	return category == CategoryPrivate || category == CategoryCGNAT
+}
+
+// IsPublic 是否是公网地址
+func IsPublic(addr netip.Addr) bool {
+	return Classify(addr) == CategoryPublic
+}
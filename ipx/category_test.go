@@ -0,0 +1,59 @@
+package ipx
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	t.Run("测试地址分类", func(t *testing.T) {
+		cases := []struct {
+			ip   string
+			want Category
+		}{
+			{"10.1.2.3", CategoryPrivate},
+			{"172.16.0.1", CategoryPrivate},
+			{"172.31.255.255", CategoryPrivate},
+			{"172.32.0.1", CategoryPublic},
+			{"192.168.1.1", CategoryPrivate},
+			{"fd00::1", CategoryPrivate},
+			{"100.64.0.1", CategoryCGNAT},
+			{"100.127.255.255", CategoryCGNAT},
+			{"100.128.0.1", CategoryPublic},
+			{"100.1.1.1", CategoryPublic},
+			{"169.254.1.1", CategoryLinkLocal},
+			{"fe80::1", CategoryLinkLocal},
+			{"127.0.0.1", CategoryLoopback},
+			{"::1", CategoryLoopback},
+			{"0.0.0.0", CategoryUnspecified},
+			{"::", CategoryUnspecified},
+			{"224.0.0.1", CategoryMulticast},
+			{"ff02::1", CategoryMulticast},
+			{"192.0.2.2", CategoryReserved},
+			{"255.255.255.255", CategoryReserved},
+			{"2001:db8::1", CategoryReserved},
+			{"8.8.8.8", CategoryPublic},
+			{"2400:3200::1", CategoryPublic},
+			{"::ffff:10.0.0.1", CategoryPrivate},
+			{"abc", CategoryInvalid},
+		}
+		for _, c := range cases {
+			if got := ClassifyString(c.ip); got != c.want {
+				t.Errorf("ClassifyString(%s) = %s, want %s", c.ip, got, c.want)
+			}
+		}
+	})
+	t.Run("测试内网地址", func(t *testing.T) {
+		for ip, want := range map[string]bool{"10.0.0.1": true, "100.64.0.1": true, "100.1.1.1": false, "fd00::1": true, "8.8.8.8": false} {
+			if got := IsIntranet(netip.MustParseAddr(ip)); got != want {
+				t.Errorf("IsIntranet(%s) = %v, want %v", ip, got, want)
+			}
+		}
+		if !IsPrivate(netip.MustParseAddr("192.168.0.1")) || IsPrivate(netip.MustParseAddr("100.64.0.1")) {
+			t.Error("IsPrivate() is fail")
+		}
+		if !IsPublic(netip.MustParseAddr("1.1.1.1")) || IsPublic(netip.Addr{}) {
+			t.Error("IsPublic() is fail")
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package ipx
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var (
+	ErrInvalidAddr   = i18n.NewError("ipx.invalid_addr")
+	ErrInvalidRange  = i18n.NewError("ipx.invalid_range")
+	ErrInvalidBits   = i18n.NewError("ipx.invalid_bits")
+	ErrTooManyPrefix = i18n.NewError("ipx.too_many_prefix")
+)
+
+// MaxSplit Split 最多返回的子网数量
+const MaxSplit = 1 << 16
+
+// ParseAddr 解析 IP，IPv4 映射的 IPv6 地址转换为 IPv4，忽略 IPv6 的 zone
+func ParseAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("%w: %s", ErrInvalidAddr, s)
+	}
+	return addr.Unmap().WithZone(""), nil
+}
+
+// ParsePrefix 解析网段，不要求主机位为0，例如 10.0.0.1/24
+// 不带长度的 IP 解析为单个地址的网段
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %s", ErrInvalidAddr, s)
+	}
+	return unmapPrefix(prefix), nil
+}
+
+// Contains 网段是否包含 IP，参数格式错误时返回 false
+// @param cidr 网段,例如 10.0.0.0/24 或者 fd00::/64
+// @param ip IP
+func Contains(cidr, ip string) bool {
+	prefix, err := ParsePrefix(cidr)
+	if err != nil {
+		return false
+	}
+	addr, err := ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	return prefix.Contains(addr)
+}
+
+// First 网段的第一个地址
+func First(prefix netip.Prefix) netip.Addr {
+	return prefix.Masked().Addr()
+}
+
+// Last 网段的最后一个地址
+func Last(prefix netip.Prefix) netip.Addr {
+	prefix = prefix.Masked()
+	b := prefix.Addr().As16()
+	offset := 128 - prefix.Addr().BitLen()
+	for i := offset + prefix.Bits(); i < 128; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr := netip.AddrFrom16(b)
+	if prefix.Addr().Is4() {
+		return addr.Unmap()
+	}
+	return addr
+}
+
+// Size 网段的地址数量，超过 uint64 时返回 0 和 false
+func Size(prefix netip.Prefix) (uint64, bool) {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= 64 {
+		return 0, false
+	}
+	return 1 << hostBits, true
+}
+
+// Each 依次遍历网段的所有地址，fn 返回 false 时停止
+func Each(prefix netip.Prefix, fn func(netip.Addr) bool) {
+	PrefixRange(prefix).Each(fn)
+}
+
+// Range 连续的地址范围，包括 From 和 To
+type Range struct {
+	From netip.Addr
+	To   netip.Addr
+}
+
+// ParseRange 解析地址范围，格式为 10.0.0.1-10.0.0.20，也可以是网段或者单个 IP
+func ParseRange(s string) (Range, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		prefix, err := ParsePrefix(s)
+		if err != nil {
+			return Range{}, err
+		}
+		return PrefixRange(prefix), nil
+	}
+	fromAddr, err := ParseAddr(from)
+	if err != nil {
+		return Range{}, err
+	}
+	toAddr, err := ParseAddr(to)
+	if err != nil {
+		return Range{}, err
+	}
+	r := Range{From: fromAddr, To: toAddr}
+	if !r.Valid() {
+		return Range{}, fmt.Errorf("%w: %s", ErrInvalidRange, s)
+	}
+	return r, nil
+}
+
+// PrefixRange 网段的地址范围
+func PrefixRange(prefix netip.Prefix) Range {
+	return Range{From: First(prefix), To: Last(prefix)}
+}
+
+// Valid 地址有效、类型相同并且 From 不大于 To
+func (r Range) Valid() bool {
+	return r.From.IsValid() && r.To.IsValid() && r.From.BitLen() == r.To.BitLen() && r.From.Compare(r.To) <= 0
+}
+
+// Contains 是否包含 IP
+func (r Range) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return r.Valid() && addr.BitLen() == r.From.BitLen() && r.From.Compare(addr) <= 0 && addr.Compare(r.To) <= 0
+}
+
+// Each 依次遍历所有地址，fn 返回 false 时停止
+func (r Range) Each(fn func(netip.Addr) bool) {
+	if !r.Valid() {
+		return
+	}
+	for addr := r.From; ; addr = addr.Next() {
+		if !fn(addr) || addr == r.To {
+			return
+		}
+	}
+}
+
+// Prefixes 覆盖地址范围的最少网段
+func (r Range) Prefixes() []netip.Prefix {
+	if !r.Valid() {
+		return nil
+	}
+	var prefixes []netip.Prefix
+	from := r.From
+	for {
+		prefix := largestPrefix(from, r.To)
+		prefixes = append(prefixes, prefix)
+		last := Last(prefix)
+		if last == r.To {
+			return prefixes
+		}
+		from = last.Next()
+	}
+}
+
+func (r Range) String() string {
+	if r.From == r.To {
+		return r.From.String()
+	}
+	return r.From.String() + "-" + r.To.String()
+}
+
+// RangeToPrefixes 地址范围转换为最少的网段，例如 10.0.0.1-10.0.0.6 转换为 10.0.0.1/32,10.0.0.2/31,10.0.0.4/31,10.0.0.6/32
+// @param from 开始地址
+// @param to 结束地址,包括该地址
+func RangeToPrefixes(from, to string) ([]netip.Prefix, error) {
+	r, err := ParseRange(from + "-" + to)
+	if err != nil {
+		return nil, err
+	}
+	return r.Prefixes(), nil
+}
+
+// Split 拆分网段为指定长度的子网，例如 10.0.0.0/24 拆分为 4 个 /26
+// @param prefix 网段
+// @param bits 子网长度,不能小于网段长度,最多返回 MaxSplit 个子网
+func Split(prefix netip.Prefix, bits int) ([]netip.Prefix, error) {
+	prefix = prefix.Masked()
+	if !prefix.IsValid() || bits < prefix.Bits() || bits > prefix.Addr().BitLen() {
+		return nil, fmt.Errorf("%w: %s /%d", ErrInvalidBits, prefix, bits)
+	}
+	if bits-prefix.Bits() > 16 {
+		return nil, fmt.Errorf("%w: %s /%d", ErrTooManyPrefix, prefix, bits)
+	}
+	count := 1 << (bits - prefix.Bits())
+	subnets := make([]netip.Prefix, 0, count)
+	addr := prefix.Addr()
+	for i := 0; i < count; i++ {
+		subnet := netip.PrefixFrom(addr, bits)
+		subnets = append(subnets, subnet)
+		addr = Last(subnet).Next()
+	}
+	return subnets, nil
+}
+
+// Aggregate 合并重叠和相邻的网段，返回排序后的最少网段，IPv4 在前
+func Aggregate(prefixes []netip.Prefix) []netip.Prefix {
+	ranges := make([]Range, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix.IsValid() {
+			ranges = append(ranges, PrefixRange(unmapPrefix(prefix)))
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].From.Less(ranges[j].From)
+	})
+	var merged []Range
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && adjacent(merged[n-1], r) {
+			if merged[n-1].To.Less(r.To) {
+				merged[n-1].To = r.To
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	var result []netip.Prefix
+	for _, r := range merged {
+		result = append(result, r.Prefixes()...)
+	}
+	return result
+}
+
+// adjacent 已排序的 b 是否和 a 重叠或者相邻
+func adjacent(a, b Range) bool {
+	if a.From.BitLen() != b.From.BitLen() {
+		return false
+	}
+	if b.From.Compare(a.To) <= 0 {
+		return true
+	}
+	next := a.To.Next()
+	return next.IsValid() && next == b.From
+}
+
+// largestPrefix 从 from 开始并且不超过 to 的最大网段
+func largestPrefix(from, to netip.Addr) netip.Prefix {
+	best := netip.PrefixFrom(from, from.BitLen())
+	for bits := from.BitLen() - 1; bits >= 0; bits-- {
+		prefix := netip.PrefixFrom(from, bits).Masked()
+		if prefix.Addr() != from || to.Less(Last(prefix)) {
+			break
+		}
+		best = prefix
+	}
+	return best
+}
+
+// unmapPrefix IPv4 映射的 IPv6 网段转换为 IPv4 网段
+func unmapPrefix(prefix netip.Prefix) netip.Prefix {
+	addr := prefix.Addr()
+	if !addr.Is4In6() {
+		return prefix
+	}
+	bits := prefix.Bits() - 96
+	if bits < 0 {
+		bits = 0
+	}
+	return netip.PrefixFrom(addr.Unmap(), bits)
+}
@@ -0,0 +1,218 @@
+package ipx
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+func prefixes(t *testing.T, ss ...string) []netip.Prefix {
+	t.Helper()
+	var ps []netip.Prefix
+	for _, s := range ss {
+		ps = append(ps, netip.MustParsePrefix(s))
+	}
+	return ps
+}
+
+func TestContains(t *testing.T) {
+	t.Run("测试网段包含", func(t *testing.T) {
+		cases := []struct {
+			cidr, ip string
+			want     bool
+		}{
+			{"10.0.0.0/8", "10.0.1.1", true},
+			{"10.0.0.1/24", "10.0.0.200", true},
+			{"10.0.0.0/8", "192.168.0.1", false},
+			{"fd00::1/64", "fd00::2", true},
+			{"fd00::1/64", "fd01::2", false},
+			{"10.0.0.0/8", "::ffff:10.0.0.1", true},
+			{"10.0.0.1", "10.0.0.1", true},
+			{"10.0.0.0/8", "abc", false},
+			{"abc", "10.0.0.1", false},
+		}
+		for _, c := range cases {
+			if got := Contains(c.cidr, c.ip); got != c.want {
+				t.Errorf("Contains(%s, %s) = %v, want %v", c.cidr, c.ip, got, c.want)
+			}
+		}
+	})
+}
+
+func TestFirstLast(t *testing.T) {
+	t.Run("测试网段首尾地址", func(t *testing.T) {
+		cases := []struct {
+			cidr, first, last string
+		}{
+			{"10.0.0.9/24", "10.0.0.0", "10.0.0.255"},
+			{"172.16.0.0/12", "172.16.0.0", "172.31.255.255"},
+			{"0.0.0.0/0", "0.0.0.0", "255.255.255.255"},
+			{"10.0.0.1/32", "10.0.0.1", "10.0.0.1"},
+			{"fd00::/64", "fd00::", "fd00::ffff:ffff:ffff:ffff"},
+		}
+		for _, c := range cases {
+			prefix := netip.MustParsePrefix(c.cidr)
+			if got := First(prefix).String(); got != c.first {
+				t.Errorf("First(%s) = %s, want %s", c.cidr, got, c.first)
+			}
+			if got := Last(prefix).String(); got != c.last {
+				t.Errorf("Last(%s) = %s, want %s", c.cidr, got, c.last)
+			}
+		}
+	})
+	t.Run("测试网段大小", func(t *testing.T) {
+		if n, ok := Size(netip.MustParsePrefix("10.0.0.0/24")); !ok || n != 256 {
+			t.Errorf("Size() = %d %v, want 256", n, ok)
+		}
+		if _, ok := Size(netip.MustParsePrefix("fd00::/64")); ok {
+			t.Error("Size() of /64 want overflow")
+		}
+	})
+}
+
+func TestRange(t *testing.T) {
+	t.Run("测试地址范围遍历", func(t *testing.T) {
+		r, err := ParseRange("10.0.0.254 - 10.0.1.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		r.Each(func(addr netip.Addr) bool {
+			got = append(got, addr.String())
+			return true
+		})
+		want := []string{"10.0.0.254", "10.0.0.255", "10.0.1.0", "10.0.1.1"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Each() = %v, want %v", got, want)
+		}
+		if r.String() != "10.0.0.254-10.0.1.1" {
+			t.Errorf("String() = %s", r.String())
+		}
+	})
+	t.Run("测试遍历提前停止", func(t *testing.T) {
+		n := 0
+		Each(netip.MustParsePrefix("10.0.0.0/24"), func(netip.Addr) bool {
+			n++
+			return n < 3
+		})
+		if n != 3 {
+			t.Errorf("Each() visited %d, want 3", n)
+		}
+	})
+	t.Run("测试遍历最后一个地址", func(t *testing.T) {
+		n := 0
+		Each(netip.MustParsePrefix("255.255.255.252/30"), func(netip.Addr) bool {
+			n++
+			return true
+		})
+		if n != 4 {
+			t.Errorf("Each() visited %d, want 4", n)
+		}
+	})
+	t.Run("测试地址范围包含", func(t *testing.T) {
+		r, _ := ParseRange("10.0.0.0/24")
+		if !r.Contains(netip.MustParseAddr("10.0.0.9")) || r.Contains(netip.MustParseAddr("10.0.1.0")) || r.Contains(netip.MustParseAddr("::a00:9")) {
+			t.Error("Contains() is fail")
+		}
+	})
+	t.Run("测试地址范围错误", func(t *testing.T) {
+		for _, s := range []string{"10.0.0.9-10.0.0.1", "10.0.0.1-fd00::1", "10.0.0.1-abc"} {
+			if _, err := ParseRange(s); err == nil {
+				t.Errorf("ParseRange(%s) want error", s)
+			}
+		}
+		if _, err := ParseRange("10.0.0.9-10.0.0.1"); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("ParseRange() error = %v, want ErrInvalidRange", err)
+		}
+	})
+}
+
+func TestRangeToPrefixes(t *testing.T) {
+	t.Run("测试地址范围转换网段", func(t *testing.T) {
+		cases := []struct {
+			from, to string
+			want     []netip.Prefix
+		}{
+			{"10.0.0.1", "10.0.0.6", prefixes(t, "10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31", "10.0.0.6/32")},
+			{"10.0.0.0", "10.0.0.255", prefixes(t, "10.0.0.0/24")},
+			{"0.0.0.0", "255.255.255.255", prefixes(t, "0.0.0.0/0")},
+			{"192.168.0.0", "192.168.2.255", prefixes(t, "192.168.0.0/23", "192.168.2.0/24")},
+			{"fd00::", "fd00::3", prefixes(t, "fd00::/126")},
+		}
+		for _, c := range cases {
+			got, err := RangeToPrefixes(c.from, c.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("RangeToPrefixes(%s, %s) = %v, want %v", c.from, c.to, got, c.want)
+			}
+		}
+	})
+}
+
+func TestSplit(t *testing.T) {
+	t.Run("测试拆分子网", func(t *testing.T) {
+		got, err := Split(netip.MustParsePrefix("10.0.0.0/24"), 26)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := prefixes(t, "10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26", "10.0.0.192/26")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Split() = %v, want %v", got, want)
+		}
+	})
+	t.Run("测试拆分 IPv6 子网", func(t *testing.T) {
+		got, err := Split(netip.MustParsePrefix("fd00::/63"), 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := prefixes(t, "fd00::/64", "fd00:0:0:1::/64"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Split() = %v, want %v", got, want)
+		}
+	})
+	t.Run("测试拆分错误", func(t *testing.T) {
+		if _, err := Split(netip.MustParsePrefix("10.0.0.0/24"), 20); !errors.Is(err, ErrInvalidBits) {
+			t.Errorf("Split() error = %v, want ErrInvalidBits", err)
+		}
+		if _, err := Split(netip.MustParsePrefix("10.0.0.0/24"), 33); !errors.Is(err, ErrInvalidBits) {
+			t.Errorf("Split() error = %v, want ErrInvalidBits", err)
+		}
+		if _, err := Split(netip.MustParsePrefix("10.0.0.0/8"), 32); !errors.Is(err, ErrTooManyPrefix) {
+			t.Errorf("Split() error = %v, want ErrTooManyPrefix", err)
+		}
+	})
+}
+
+func TestAggregate(t *testing.T) {
+	t.Run("测试合并网段", func(t *testing.T) {
+		in := prefixes(t,
+			"10.0.0.128/25", "10.0.0.0/25", "10.0.1.0/24",
+			"10.0.0.5/32", "192.168.1.0/24", "fd00::/64", "fd00:0:0:1::/64", "::ffff:172.16.0.0/108",
+		)
+		want := prefixes(t, "10.0.0.0/23", "172.16.0.0/12", "192.168.1.0/24", "fd00::/63")
+		if got := Aggregate(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("Aggregate() = %v, want %v", got, want)
+		}
+	})
+	t.Run("测试合并空网段", func(t *testing.T) {
+		if got := Aggregate(nil); len(got) != 0 {
+			t.Errorf("Aggregate(nil) = %v", got)
+		}
+	})
+}
+
+func TestMessages(t *testing.T) {
+	t.Run("测试错误信息翻译", func(t *testing.T) {
+		for _, err := range []error{ErrInvalidAddr, ErrInvalidRange, ErrInvalidBits, ErrTooManyPrefix} {
+			for _, lang := range []i18n.Lang{i18n.ZhCN, i18n.EnUS} {
+				if !i18n.Default.Has(lang, err.(*i18n.Error).Key) {
+					t.Errorf("%s 缺少 %s", lang, err.(*i18n.Error).Key)
+				}
+			}
+		}
+	})
+}
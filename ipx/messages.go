@@ -0,0 +1,21 @@
+package ipx
+
+import (
+	"github.com/snowlyg/helper/i18n"
+)
+
+// 地址计算错误信息，使用 i18n.SetLang 切换语言
+func init() {
+	i18n.Register(i18n.ZhCN, map[string]string{
+		"ipx.invalid_addr":    "IP 地址格式错误",
+		"ipx.invalid_range":   "IP 地址范围错误",
+		"ipx.invalid_bits":    "子网长度错误",
+		"ipx.too_many_prefix": "子网数量过多",
+	})
+	i18n.Register(i18n.EnUS, map[string]string{
+		"ipx.invalid_addr":    "invalid IP address",
+		"ipx.invalid_range":   "invalid IP range",
+		"ipx.invalid_bits":    "invalid subnet prefix length",
+		"ipx.too_many_prefix": "too many subnets",
+	})
+}
@@ -0,0 +1,45 @@
+package ping
+
+import (
+	"github.com/snowlyg/helper/i18n"
+)
+
+// ping 提示和错误信息，使用 i18n.SetLang 切换语言
+func init() {
+	i18n.Register(i18n.ZhCN, map[string]string{
+		"ping.code.ok":             "设备ip(%s)可以访问",
+		"ping.code.partial_loss":   "设备ip(%s)可以访问,丢包率%.0f%%",
+		"ping.code.timeout":        "设备(%s)可能已离线或者网络不稳定",
+		"ping.code.resolve_failed": "设备(%s)地址解析失败: %s",
+		"ping.empty_address":       "设备ip为空，请检查设备是否绑定ip",
+		"ping.cidr_too_large":      "网段地址过多",
+		"ping.no_reply":            "设备没有响应",
+		"ping.http_status":         "HTTP 状态码错误",
+		"ping.http_body":           "HTTP 响应内容错误",
+		"ping.body_missing":        "不包含 %q",
+		"ping.udp_reply":           "UDP 响应内容错误",
+		"ping.permission":          "ping 没有权限,缺少 %s,%s",
+		"ping.fix_raw":             "使用 root 运行或者执行 setcap cap_net_raw+ep %s",
+		"ping.fix_group":           "执行 sysctl -w %s=\"0 2147483647\" 允许 udp icmp",
+		"ping.or":                  "%s 或者 %s",
+		"ping.group_range":         "net.ipv4.ping_group_range 格式错误",
+	})
+	i18n.Register(i18n.EnUS, map[string]string{
+		"ping.code.ok":             "device %s is reachable",
+		"ping.code.partial_loss":   "device %s is reachable with %.0f%% packet loss",
+		"ping.code.timeout":        "device %s may be offline or the network is unstable",
+		"ping.code.resolve_failed": "failed to resolve device %s: %s",
+		"ping.empty_address":       "device IP is empty, check that the device has an IP bound",
+		"ping.cidr_too_large":      "too many addresses in CIDR range",
+		"ping.no_reply":            "no reply from device",
+		"ping.http_status":         "unexpected HTTP status",
+		"ping.http_body":           "unexpected HTTP response body",
+		"ping.body_missing":        "missing %q",
+		"ping.udp_reply":           "unexpected UDP reply",
+		"ping.permission":          "ping permission denied, missing %s, %s",
+		"ping.fix_raw":             "run as root or run setcap cap_net_raw+ep %s",
+		"ping.fix_group":           "run sysctl -w %s=\"0 2147483647\" to allow UDP ICMP",
+		"ping.or":                  "%s or %s",
+		"ping.group_range":         "invalid net.ipv4.ping_group_range",
+	})
+}
@@ -0,0 +1,239 @@
+package ping
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-ping/ping"
+)
+
+// State 设备在线状态
+type State int
+
+const (
+	StateUnknown State = iota //刚加入,还没有达到阈值
+	StateUp                   //在线
+	StateDown                 //离线
+)
+
+func (s State) String() string {
+	switch s {
+	case StateUp:
+		return "up"
+	case StateDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// Event 状态变化事件
+type Event struct {
+	Host     string
+	State    State         //新状态
+	Prev     State         //旧状态
+	Time     time.Time     //状态变化时间
+	Duration time.Duration //旧状态持续时间
+	Stats    *ping.Statistics
+	Err      error
+}
+
+// MonitorOptions 监控配置
+type MonitorOptions struct {
+	Options
+	Every         time.Duration //探测周期,默认30秒
+	DownThreshold int           //连续失败次数达到后标记为离线,默认3
+	UpThreshold   int           //连续成功次数达到后标记为在线,默认1
+	Concurrency   int           //每轮探测的并发数,默认64
+}
+
+// withDefaults 使用默认配置补全零值字段
+func (o MonitorOptions) withDefaults() MonitorOptions {
+	if o.Every <= 0 {
+		o.Every = 30 * time.Second
+	}
+	if o.DownThreshold <= 0 {
+		o.DownThreshold = 3
+	}
+	if o.UpThreshold <= 0 {
+		o.UpThreshold = 1
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+	return o
+}
+
+// target 监控目标
+type target struct {
+	state     State
+	since     time.Time //当前状态开始时间
+	fails     int       //连续失败次数
+	successes int       //连续成功次数
+}
+
+// Monitor 周期性 ping 多个设备，连续失败或者成功达到阈值后发送状态变化事件
+//
+//	m := ping.NewMonitor(ping.MonitorOptions{Every: time.Minute})
+//	m.Add("10.0.0.1", "10.0.0.2")
+//	events, cancel := m.Subscribe(16)
+//	defer cancel()
+//	go m.Run(ctx)
+//	for e := range events {
+//		fmt.Println(e.Host, e.Prev, "->", e.State, e.Duration)
+//	}
+type Monitor struct {
+	opts    MonitorOptions
+	mu      sync.Mutex
+	targets map[string]*target
+	subs    map[chan Event]struct{}
+	probe   func(ctx context.Context, host string, opts Options) (*ping.Statistics, error)
+}
+
+// NewMonitor 创建监控
+// @param opts 配置
+func NewMonitor(opts MonitorOptions) *Monitor {
+	return &Monitor{
+		opts:    opts.withDefaults(),
+		targets: map[string]*target{},
+		subs:    map[chan Event]struct{}{},
+		probe:   Probe,
+	}
+}
+
+// Add 添加监控目标，已存在的目标不变，运行中添加的目标在下一轮探测
+func (m *Monitor) Add(hosts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, host := range hosts {
+		if _, ok := m.targets[host]; !ok && host != "" {
+			m.targets[host] = &target{since: time.Now()}
+		}
+	}
+}
+
+// Remove 移除监控目标，不会发送事件
+func (m *Monitor) Remove(hosts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, host := range hosts {
+		delete(m.targets, host)
+	}
+}
+
+// Targets 监控目标，按地址排序
+func (m *Monitor) Targets() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hosts := make([]string, 0, len(m.targets))
+	for host := range m.targets {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// State 目标当前状态，目标不存在时 ok 为 false
+func (m *Monitor) State(host string) (state State, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.targets[host]
+	if !ok {
+		return StateUnknown, false
+	}
+	return t.state, true
+}
+
+// Subscribe 订阅状态变化事件，调用返回的函数取消订阅并关闭通道
+// 通道已满时事件会被丢弃，不会阻塞探测
+// @param buffer 通道缓冲大小
+func (m *Monitor) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	m.mu.Lock()
+	m.subs[ch] = struct{}{}
+	m.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.subs, ch)
+			m.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Run 按周期探测所有目标，直到 ctx 取消
+func (m *Monitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.opts.Every)
+	defer ticker.Stop()
+	for {
+		m.round(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// round 并发探测一轮所有目标
+func (m *Monitor) round(ctx context.Context) {
+	hosts := m.Targets()
+	sem := make(chan struct{}, m.opts.Concurrency)
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(host string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			stats, err := m.probe(ctx, host, m.opts.Options)
+			if ctx.Err() != nil {
+				// 取消时的结果不计入
+				return
+			}
+			m.update(host, err == nil && stats != nil && stats.PacketsRecv > 0, stats, err)
+		}(host)
+	}
+	wg.Wait()
+}
+
+// update 更新目标的连续成功和失败次数，达到阈值时发送事件
+func (m *Monitor) update(host string, up bool, stats *ping.Statistics, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.targets[host]
+	if !ok {
+		// 探测期间被移除
+		return
+	}
+	next := t.state
+	if up {
+		t.fails = 0
+		t.successes++
+		if t.successes >= m.opts.UpThreshold {
+			next = StateUp
+		}
+	} else {
+		t.successes = 0
+		t.fails++
+		if t.fails >= m.opts.DownThreshold {
+			next = StateDown
+		}
+	}
+	if next == t.state {
+		return
+	}
+	now := time.Now()
+	event := Event{Host: host, State: next, Prev: t.state, Time: now, Duration: now.Sub(t.since), Stats: stats, Err: err}
+	t.state, t.since = next, now
+	for ch := range m.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-ping/ping"
+)
+
+// fakeProbe 可以设置每个主机在线状态的探测
+type fakeProbe struct {
+	mu sync.Mutex
+	up map[string]bool
+}
+
+func (f *fakeProbe) set(host string, up bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.up[host] = up
+}
+
+func (f *fakeProbe) probe(ctx context.Context, host string, opts Options) (*ping.Statistics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := &ping.Statistics{Addr: host, PacketsSent: 1}
+	if f.up[host] {
+		stats.PacketsRecv = 1
+	}
+	return stats, nil
+}
+
+func newFakeMonitor(opts MonitorOptions) (*Monitor, *fakeProbe) {
+	f := &fakeProbe{up: map[string]bool{}}
+	m := NewMonitor(opts)
+	m.probe = f.probe
+	return m, f
+}
+
+// states 读取通道中已有的事件
+func states(events <-chan Event) []State {
+	var got []State
+	for {
+		select {
+		case e := <-events:
+			got = append(got, e.State)
+		default:
+			return got
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	m, f := newFakeMonitor(MonitorOptions{DownThreshold: 2, UpThreshold: 2})
+	m.Add("10.0.0.1", "10.0.0.2", "10.0.0.1")
+	events, cancel := m.Subscribe(16)
+	defer cancel()
+	ctx := context.Background()
+
+	t.Run("测试监控目标", func(t *testing.T) {
+		if got, want := m.Targets(), []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Targets() want %v but get %v", want, got)
+		}
+	})
+	t.Run("测试上线阈值", func(t *testing.T) {
+		f.set("10.0.0.1", true)
+		m.round(ctx)
+		if got := states(events); len(got) != 0 {
+			t.Errorf("round() want no events but get %v", got)
+		}
+		m.round(ctx)
+		got := map[string]Event{}
+		for i := 0; i < 2; i++ {
+			e := <-events
+			got[e.Host] = e
+		}
+		if e := got["10.0.0.1"]; e.State != StateUp || e.Prev != StateUnknown || e.Duration <= 0 {
+			t.Errorf("round() 10.0.0.1 want up but get %+v", e)
+		}
+		if e := got["10.0.0.2"]; e.State != StateDown || e.Prev != StateUnknown {
+			t.Errorf("round() 10.0.0.2 want down but get %+v", e)
+		}
+	})
+	t.Run("测试离线阈值", func(t *testing.T) {
+		f.set("10.0.0.1", false)
+		m.round(ctx)
+		f.set("10.0.0.1", true)
+		m.round(ctx)
+		if got := states(events); len(got) != 0 {
+			t.Errorf("round() want no events but get %v", got)
+		}
+		f.set("10.0.0.1", false)
+		m.round(ctx)
+		m.round(ctx)
+		if got := states(events); !reflect.DeepEqual(got, []State{StateDown}) {
+			t.Errorf("round() want down but get %v", got)
+		}
+		if state, ok := m.State("10.0.0.1"); !ok || state != StateDown {
+			t.Errorf("State() want %s but get %s", StateDown, state)
+		}
+	})
+	t.Run("测试移除目标", func(t *testing.T) {
+		m.Remove("10.0.0.1")
+		if _, ok := m.State("10.0.0.1"); ok {
+			t.Error("State() want removed")
+		}
+		f.set("10.0.0.1", true)
+		m.round(ctx)
+		m.round(ctx)
+		if got := states(events); len(got) != 0 {
+			t.Errorf("round() want no events but get %v", got)
+		}
+	})
+	t.Run("测试取消订阅", func(t *testing.T) {
+		cancel()
+		if _, ok := <-events; ok {
+			t.Error("Subscribe() want closed")
+		}
+		cancel()
+	})
+}
+
+func TestMonitorRun(t *testing.T) {
+	m, f := newFakeMonitor(MonitorOptions{Every: 10 * time.Millisecond})
+	events, cancel := m.Subscribe(1)
+	defer cancel()
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(ctx)
+	}()
+
+	t.Run("测试运行中添加目标", func(t *testing.T) {
+		f.set("10.0.0.3", true)
+		m.Add("10.0.0.3")
+		select {
+		case e := <-events:
+			if e.Host != "10.0.0.3" || e.State != StateUp {
+				t.Errorf("Run() get %+v", e)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run() no events")
+		}
+	})
+	t.Run("测试停止", func(t *testing.T) {
+		stop()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() want %v but get %v", context.Canceled, err)
+		}
+	})
+}
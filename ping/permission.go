@@ -0,0 +1,128 @@
+package ping
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var ErrPingGroupRange = i18n.NewError("ping.group_range")
+
+const (
+	capNetRaw       = 13 //CAP_NET_RAW 在 CapEff 中的位
+	pingGroupSysctl = "net.ipv4.ping_group_range"
+)
+
+// PermissionError ping 没有权限，Missing 为缺少的权限，Fix 为修复方法
+type PermissionError struct {
+	Privileged bool   //是否请求了 raw socket
+	Missing    string //CAP_NET_RAW 或者 net.ipv4.ping_group_range
+	Fix        string
+	Err        error
+	rawOnly    bool //只能使用 raw socket,例如 traceroute
+}
+
+func (e *PermissionError) Error() string {
+	return e.Localize(i18n.CurrentLang())
+}
+
+// Localize 指定语言的错误信息
+func (e *PermissionError) Localize(lang i18n.Lang) string {
+	missing, fix := e.hint(lang)
+	msg := i18n.Sprintf(lang, "ping.permission", missing, fix)
+	if e.Err != nil {
+		msg += ": " + i18n.Localize(e.Err, lang)
+	}
+	return msg
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+// hint 缺少的权限和修复方法
+func (e *PermissionError) hint(lang i18n.Lang) (missing, fix string) {
+	exe, _ := os.Executable()
+	if exe == "" {
+		exe = "<program>"
+	}
+	rawFix := i18n.Sprintf(lang, "ping.fix_raw", exe)
+	groupFix := i18n.Sprintf(lang, "ping.fix_group", pingGroupSysctl)
+	switch {
+	case e.rawOnly:
+		return "CAP_NET_RAW", rawFix
+	case !e.Privileged:
+		return pingGroupSysctl, groupFix
+	}
+	return i18n.Sprintf(lang, "ping.or", "CAP_NET_RAW", pingGroupSysctl), i18n.Sprintf(lang, "ping.or", rawFix, groupFix)
+}
+
+// newPermissionError 根据请求的模式生成权限错误
+// @param privileged 是否请求了 raw socket,为 true 时 raw socket 和 udp icmp 都不可用
+func newPermissionError(privileged bool, err error) *PermissionError {
+	if err == nil {
+		err = os.ErrPermission
+	}
+	e := &PermissionError{Privileged: privileged, Err: err}
+	e.Missing, e.Fix = e.hint(i18n.CurrentLang())
+	return e
+}
+
+// newRawPermissionError 只能使用 raw socket 时的权限错误，例如 traceroute
+func newRawPermissionError(err error) *PermissionError {
+	e := &PermissionError{Privileged: true, Err: err, rawOnly: true}
+	e.Missing, e.Fix = e.hint(i18n.CurrentLang())
+	return e
+}
+
+// CheckPermission 检查 ping 权限，可以在启动时调用
+// 有 raw socket 权限时 privileged 为 true，只能使用 udp icmp 时为 false，都不可用时返回 *PermissionError
+func CheckPermission() (privileged bool, err error) {
+	if rawAllowed() {
+		return true, nil
+	}
+	if unprivilegedAllowed() {
+		return false, nil
+	}
+	return false, newPermissionError(true, nil)
+}
+
+// parseCapEff 从 /proc/self/status 中读取 CapEff 并判断是否有某个权限
+func parseCapEff(status string, bit uint) bool {
+	for _, line := range strings.Split(status, "\n") {
+		if !strings.HasPrefix(line, "CapEff:") {
+			continue
+		}
+		caps, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(line, "CapEff:")), 16, 64)
+		return err == nil && caps&(1<<bit) != 0
+	}
+	return false
+}
+
+// parsePingGroupRange 解析 ping_group_range，例如 0 2147483647，默认 1 0 表示不允许
+func parsePingGroupRange(value string) (low, high int, err error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("%w: %q", ErrPingGroupRange, value)
+	}
+	if low, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrPingGroupRange, value)
+	}
+	if high, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrPingGroupRange, value)
+	}
+	return low, high, nil
+}
+
+// groupAllowed 用户组是否在 ping_group_range 范围内
+func groupAllowed(low, high int, gids []int) bool {
+	for _, gid := range gids {
+		if gid >= low && gid <= high {
+			return true
+		}
+	}
+	return false
+}
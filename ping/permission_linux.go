@@ -0,0 +1,28 @@
+package ping
+
+import (
+	"os"
+)
+
+// rawAllowed 是否有 CAP_NET_RAW 权限
+func rawAllowed() bool {
+	status, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return os.Geteuid() == 0
+	}
+	return parseCapEff(string(status), capNetRaw)
+}
+
+// unprivilegedAllowed 当前用户组是否在 net.ipv4.ping_group_range 范围内
+func unprivilegedAllowed() bool {
+	value, err := os.ReadFile("/proc/sys/net/ipv4/ping_group_range")
+	if err != nil {
+		return false
+	}
+	low, high, err := parsePingGroupRange(string(value))
+	if err != nil {
+		return false
+	}
+	gids, _ := os.Getgroups()
+	return groupAllowed(low, high, append(gids, os.Getgid(), os.Getegid()))
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package ping
+
+import (
+	"os"
+	"runtime"
+)
+
+// rawAllowed windows 不需要权限，其他系统需要 root
+func rawAllowed() bool {
+	return runtime.GOOS == "windows" || os.Geteuid() == 0
+}
+
+// unprivilegedAllowed macOS 等系统默认支持 udp icmp，windows 不支持
+func unprivilegedAllowed() bool {
+	return runtime.GOOS != "windows"
+}
@@ -0,0 +1,94 @@
+package ping
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+func TestParseCapEff(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   bool
+	}{
+		{name: "root", status: "Name:\tping\nCapPrm:\t000001ffffffffff\nCapEff:\t000001ffffffffff\n", want: true},
+		{name: "cap_net_raw", status: "CapEff:\t0000000000002000\n", want: true},
+		{name: "普通用户", status: "CapEff:\t0000000000000000\n", want: false},
+		{name: "没有 CapEff", status: "Name:\tping\n", want: false},
+	}
+	for _, tt := range tests {
+		t.Run("测试解析 CapEff:"+tt.name, func(t *testing.T) {
+			if got := parseCapEff(tt.status, capNetRaw); got != tt.want {
+				t.Errorf("parseCapEff() want %v but get %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPingGroupRange(t *testing.T) {
+	t.Run("测试默认不允许", func(t *testing.T) {
+		low, high, err := parsePingGroupRange("1\t0\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if groupAllowed(low, high, []int{0, 1, 1000}) {
+			t.Error("groupAllowed() want false")
+		}
+	})
+	t.Run("测试允许用户组", func(t *testing.T) {
+		low, high, err := parsePingGroupRange("0 2147483647")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !groupAllowed(low, high, []int{1000}) {
+			t.Error("groupAllowed() want true")
+		}
+	})
+	t.Run("测试格式错误", func(t *testing.T) {
+		for _, value := range []string{"0", "a 1", "0 b"} {
+			if _, _, err := parsePingGroupRange(value); !errors.Is(err, ErrPingGroupRange) {
+				t.Errorf("parsePingGroupRange(%q) want %v but get %v", value, ErrPingGroupRange, err)
+			}
+		}
+		if got := i18n.Localize(ErrPingGroupRange, i18n.EnUS); got != "invalid net.ipv4.ping_group_range" {
+			t.Errorf("Localize(en-US) get %s", got)
+		}
+	})
+}
+
+func TestPermissionError(t *testing.T) {
+	t.Run("测试 raw socket 权限错误", func(t *testing.T) {
+		err := error(newPermissionError(true, os.NewSyscallError("socket", syscall.EPERM)))
+		var permErr *PermissionError
+		if !errors.As(err, &permErr) || !errors.Is(err, os.ErrPermission) || Classify(err) != KindPermission {
+			t.Fatalf("newPermissionError() get %v", err)
+		}
+		if !strings.Contains(err.Error(), "CAP_NET_RAW") || !strings.Contains(permErr.Fix, "setcap cap_net_raw+ep") {
+			t.Errorf("Error() get %s", err)
+		}
+	})
+	t.Run("测试 udp icmp 权限错误", func(t *testing.T) {
+		err := newPermissionError(false, nil)
+		if !errors.Is(err, os.ErrPermission) || err.Missing != pingGroupSysctl || strings.Contains(err.Fix, "setcap") {
+			t.Errorf("newPermissionError() get %v", err)
+		}
+	})
+}
+
+func TestCheckPermission(t *testing.T) {
+	t.Run("测试检查权限", func(t *testing.T) {
+		privileged, err := CheckPermission()
+		var permErr *PermissionError
+		if err != nil && !errors.As(err, &permErr) {
+			t.Errorf("CheckPermission() want *PermissionError but get %v", err)
+		}
+		if privileged != rawAllowed() {
+			t.Errorf("CheckPermission() want %v but get %v", rawAllowed(), privileged)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"time"
+
+	"github.com/go-ping/ping"
+	"github.com/snowlyg/helper/i18n"
+	"github.com/snowlyg/helper/sys"
+)
+
+var ErrHostEmpty = i18n.NewError("ping.empty_address")
+
+// Options ping 配置，字段为零值时使用默认配置，Privileged 只决定优先使用的方式，没有权限时会尝试另一种
+type Options struct {
+	Count      int           //发送次数,默认3
+	Size       int           //数据包大小,默认548,最小24
+	Interval   time.Duration //发送间隔,默认500毫秒
+	Timeout    time.Duration //总超时时间,默认1500毫秒
+	TTL        int           //默认64
+	Privileged bool          //优先使用 raw socket,需要 root 或者 CAP_NET_RAW,否则优先使用 udp icmp
+	Family     sys.IPFamily  //域名解析的地址类型,默认 IPv4,IPDual 时 IPv4 优先
+}
+
+// DefaultOptions 默认配置，和 GetPingMsg 一致
+func DefaultOptions() Options {
+	return Options{
+		Count: 3,
+		// 修改相关问题https://githubmemory.com/repo/go-ping/ping/issues/168
+		Size:       548,
+		Interval:   500 * time.Millisecond,
+		Timeout:    1500 * time.Millisecond,
+		TTL:        64,
+		Privileged: true,
+	}
+}
+
+// withDefaults 使用默认配置补全零值字段
+func (o Options) withDefaults() Options {
+	def := DefaultOptions()
+	if o.Count <= 0 {
+		o.Count = def.Count
+	}
+	if o.Size <= 0 {
+		o.Size = def.Size
+	}
+	if o.Interval <= 0 {
+		o.Interval = def.Interval
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = def.Timeout
+	}
+	if o.TTL <= 0 {
+		o.TTL = def.TTL
+	}
+	return o
+}
+
+// Probe ping 主机，返回丢包率、往返时间等统计信息
+// Privileged 为 true 但是没有 raw socket 权限时，如果 ping_group_range 允许则自动使用 udp icmp
+// Privileged 为 false 但是没有 udp icmp 权限时，如果有 CAP_NET_RAW 则自动使用 raw socket，都不可用时返回 *PermissionError
+// 收到 Count 个响应或者超时后返回，ctx 取消时停止发送并返回已有的统计信息和 ctx 的错误
+// 统计信息中的 Addr 为解析后的 IP
+// @param host IP 或者域名
+// @param opts 配置
+func Probe(ctx context.Context, host string, opts Options) (*ping.Statistics, error) {
+	if host == "" {
+		return nil, ErrHostEmpty
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	ipaddr, err := resolve(ctx, host, opts.Family)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := run(ctx, newPinger(host, ipaddr, opts, opts.Privileged))
+	if err == nil || !errors.Is(err, os.ErrPermission) {
+		return stats, err
+	}
+	// 没有权限时尝试另一种方式
+	allowed := unprivilegedAllowed
+	if !opts.Privileged {
+		allowed = rawAllowed
+	}
+	if allowed() {
+		stats, err = run(ctx, newPinger(host, ipaddr, opts, !opts.Privileged))
+		if err == nil || !errors.Is(err, os.ErrPermission) {
+			return stats, err
+		}
+	}
+	return stats, newPermissionError(opts.Privileged, err)
+}
+
+// newPinger 使用配置创建 pinger
+func newPinger(host string, ipaddr *net.IPAddr, opts Options, privileged bool) *ping.Pinger {
+	pinger := ping.New(host)
+	pinger.SetIPAddr(ipaddr)
+	pinger.Count = opts.Count
+	pinger.Size = opts.Size
+	pinger.Interval = opts.Interval
+	pinger.Timeout = opts.Timeout
+	pinger.TTL = opts.TTL
+	pinger.SetPrivileged(privileged)
+	return pinger
+}
+
+// run 执行 ping，ctx 取消时停止
+func run(ctx context.Context, pinger *ping.Pinger) (*ping.Statistics, error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- pinger.Run()
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		pinger.Stop()
+		if err = <-done; err == nil {
+			err = ctx.Err()
+		}
+	}
+	return pinger.Statistics(), err
+}
+
+// resolve 解析主机地址，IP 直接使用
+// @param family 地址类型
+func resolve(ctx context.Context, host string, family sys.IPFamily) (*net.IPAddr, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return &net.IPAddr{IP: ip}, nil
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, family.Network("ip"), host)
+	if err != nil {
+		return nil, err
+	}
+	ips = sys.SortIPs(ips, family)
+	if len(ips) == 0 {
+		return nil, &net.DNSError{Err: "no " + family.String() + " address", Name: host, IsNotFound: true}
+	}
+	return &net.IPAddr{IP: ips[0]}, nil
+}
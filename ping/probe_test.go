@@ -0,0 +1,78 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOptionsWithDefaults(t *testing.T) {
+	t.Run("测试默认配置", func(t *testing.T) {
+		got := Options{Count: 5}.withDefaults()
+		want := DefaultOptions()
+		want.Count = 5
+		// Privileged 零值优先使用 udp icmp，没有权限时使用 raw socket
+		want.Privileged = false
+		if got != want {
+			t.Errorf("withDefaults() want %+v but get %+v", want, got)
+		}
+	})
+}
+
+func TestProbe(t *testing.T) {
+	t.Run("测试设备ip为空", func(t *testing.T) {
+		if _, err := Probe(context.Background(), "", DefaultOptions()); !errors.Is(err, ErrHostEmpty) {
+			t.Errorf("Probe() want %v but get %v", ErrHostEmpty, err)
+		}
+	})
+	t.Run("测试取消", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := Probe(ctx, "127.0.0.1", DefaultOptions()); !errors.Is(err, context.Canceled) {
+			t.Errorf("Probe() want %v but get %v", context.Canceled, err)
+		}
+	})
+	t.Run("测试 ping 本机", func(t *testing.T) {
+		opts := DefaultOptions()
+		opts.Count = 2
+		opts.Interval = 100 * time.Millisecond
+		stats, err := Probe(context.Background(), "127.0.0.1", opts)
+		if errors.Is(err, os.ErrPermission) {
+			t.Skip(err)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.PacketsSent != 2 || stats.PacketsRecv != 2 || stats.PacketLoss != 0 || len(stats.Rtts) != 2 {
+			t.Errorf("Probe() get %+v", stats)
+		}
+	})
+	t.Run("测试零值配置 ping 本机", func(t *testing.T) {
+		if _, err := CheckPermission(); err != nil {
+			t.Skip(err)
+		}
+		stats, err := Probe(context.Background(), "127.0.0.1", Options{Count: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.PacketsRecv != 1 {
+			t.Errorf("Probe() get %+v", stats)
+		}
+	})
+	t.Run("测试 ping6 本机", func(t *testing.T) {
+		opts := DefaultOptions()
+		opts.Count = 1
+		stats, err := Probe(context.Background(), "::1", opts)
+		if errors.Is(err, os.ErrPermission) {
+			t.Skip(err.Error())
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.IPAddr.IP.To4() != nil || stats.PacketsRecv != 1 {
+			t.Errorf("Probe() get %+v", stats)
+		}
+	})
+}
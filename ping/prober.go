@@ -0,0 +1,273 @@
+package ping
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-ping/ping"
+	"github.com/snowlyg/helper/i18n"
+)
+
+var (
+	ErrNoReply    = i18n.NewError("ping.no_reply")
+	ErrStatusCode = i18n.NewError("ping.http_status")
+	ErrBody       = i18n.NewError("ping.http_body")
+	ErrUDPReply   = i18n.NewError("ping.udp_reply")
+)
+
+const defaultProbeTimeout = 1500 * time.Millisecond
+
+// ErrorKind 探测失败原因分类
+type ErrorKind string
+
+const (
+	KindNone        ErrorKind = ""            //成功
+	KindTimeout     ErrorKind = "timeout"     //超时或者没有响应
+	KindRefused     ErrorKind = "refused"     //端口未监听
+	KindUnreachable ErrorKind = "unreachable" //主机或者网络不可达
+	KindDNS         ErrorKind = "dns"         //域名解析失败
+	KindTLS         ErrorKind = "tls"         //证书或者 TLS 握手失败
+	KindPermission  ErrorKind = "permission"  //没有权限,例如 raw socket
+	KindStatus      ErrorKind = "status"      //HTTP 状态码不符合
+	KindBody        ErrorKind = "body"        //HTTP 或者 UDP 响应内容不符合
+	KindCanceled    ErrorKind = "canceled"    //ctx 取消
+	KindUnknown     ErrorKind = "unknown"     //其他错误
+)
+
+// Result 探测结果
+type Result struct {
+	Target     string
+	Protocol   string //icmp,tcp,udp,http
+	Up         bool
+	Latency    time.Duration    //icmp 为平均往返时间,其他为请求耗时
+	Kind       ErrorKind        //失败原因
+	Err        error            //失败时的错误
+	Stats      *ping.Statistics //icmp 的统计信息
+	StatusCode int              //http 的状态码
+}
+
+// Prober 探测设备是否可用，不同协议的 target 格式不同
+type Prober interface {
+	Probe(ctx context.Context, target string) *Result
+}
+
+// ICMPProber icmp ping 探测，target 为 IP 或者域名
+type ICMPProber struct {
+	Options Options
+}
+
+// Probe ping 设备，至少收到一个响应时可用
+func (p *ICMPProber) Probe(ctx context.Context, target string) *Result {
+	stats, err := Probe(ctx, target, p.Options)
+	result := &Result{Target: target, Protocol: "icmp", Stats: stats}
+	if err == nil && stats.PacketsRecv == 0 {
+		err = ErrNoReply
+	}
+	if stats != nil {
+		result.Latency = stats.AvgRtt
+	}
+	return result.finish(err)
+}
+
+// TCPProber tcp 连接探测，target 为 host:port
+type TCPProber struct {
+	Timeout time.Duration //默认1500毫秒
+}
+
+// Probe 连接成功时可用
+func (p *TCPProber) Probe(ctx context.Context, target string) *Result {
+	result := &Result{Target: target, Protocol: "tcp"}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout(p.Timeout))
+	defer cancel()
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", target)
+	result.Latency = time.Since(start)
+	if err == nil {
+		conn.Close()
+	}
+	return result.finish(err)
+}
+
+// UDPProber udp echo 探测，target 为 host:port
+// 发送 Payload 后等待响应，Expect 为空时要求响应和 Payload 一致
+type UDPProber struct {
+	Timeout time.Duration //默认1500毫秒
+	Payload []byte        //默认 ping
+	Expect  []byte        //响应需要包含的内容
+}
+
+// Probe 收到符合的响应时可用，端口未监听时系统返回 icmp 端口不可达
+func (p *UDPProber) Probe(ctx context.Context, target string) *Result {
+	result := &Result{Target: target, Protocol: "udp"}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout(p.Timeout))
+	defer cancel()
+	payload := p.Payload
+	if len(payload) == 0 {
+		payload = []byte("ping")
+	}
+	expect := p.Expect
+	if len(expect) == 0 {
+		expect = payload
+	}
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", target)
+	if err != nil {
+		return result.finish(err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	if _, err := conn.Write(payload); err != nil {
+		return result.finish(ctxErr(ctx, err))
+	}
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	result.Latency = time.Since(start)
+	if err != nil {
+		return result.finish(ctxErr(ctx, err))
+	}
+	if !bytes.Contains(buf[:n], expect) {
+		return result.finish(fmt.Errorf("%w: %q", ErrUDPReply, buf[:n]))
+	}
+	return result.finish(nil)
+}
+
+// HTTPProber http(s) GET 探测，target 为 url
+type HTTPProber struct {
+	Timeout            time.Duration //默认1500毫秒
+	Client             *http.Client  //为空时使用内置客户端
+	Header             http.Header
+	ExpectStatus       int    //期望的状态码,默认200到399
+	ExpectBody         string //响应内容需要包含的字符串
+	InsecureSkipVerify bool   //跳过证书校验,Client 为空时有效
+}
+
+// Probe 状态码和响应内容符合时可用
+func (p *HTTPProber) Probe(ctx context.Context, target string) *Result {
+	result := &Result{Target: target, Protocol: "http"}
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout(p.Timeout))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return result.finish(err)
+	}
+	for k, v := range p.Header {
+		req.Header[k] = v
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Transport: &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: p.InsecureSkipVerify},
+			DisableKeepAlives: true,
+		}}
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Latency = time.Since(start)
+		return result.finish(err)
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	var body []byte
+	if p.ExpectBody != "" {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}
+	result.Latency = time.Since(start)
+	switch {
+	case err != nil:
+		return result.finish(err)
+	case p.ExpectStatus > 0 && resp.StatusCode != p.ExpectStatus,
+		p.ExpectStatus <= 0 && (resp.StatusCode < 200 || resp.StatusCode >= 400):
+		return result.finish(fmt.Errorf("%w: %s", ErrStatusCode, resp.Status))
+	case p.ExpectBody != "" && !strings.Contains(string(body), p.ExpectBody):
+		return result.finish(fmt.Errorf("%w: %s", ErrBody, i18n.Message("ping.body_missing", p.ExpectBody)))
+	}
+	return result.finish(nil)
+}
+
+// finish 设置结果的错误和分类
+func (r *Result) finish(err error) *Result {
+	r.Err = err
+	r.Kind = Classify(err)
+	r.Up = err == nil
+	return r
+}
+
+// Classify 错误分类
+func Classify(err error) ErrorKind {
+	var (
+		dnsErr     *net.DNSError
+		netErr     net.Error
+		certErr    x509.CertificateInvalidError
+		hostErr    x509.HostnameError
+		authErr    x509.UnknownAuthorityError
+		recordErr  tls.RecordHeaderError
+		unreachErr syscall.Errno
+	)
+	switch {
+	case err == nil:
+		return KindNone
+	case errors.Is(err, context.Canceled):
+		return KindCanceled
+	case errors.Is(err, ErrStatusCode):
+		return KindStatus
+	case errors.Is(err, ErrBody), errors.Is(err, ErrUDPReply):
+		return KindBody
+	case errors.Is(err, ErrNoReply), errors.Is(err, context.DeadlineExceeded):
+		return KindTimeout
+	case errors.As(err, &dnsErr):
+		return KindDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return KindRefused
+	case errors.As(err, &unreachErr) && (unreachErr == syscall.EHOSTUNREACH || unreachErr == syscall.ENETUNREACH):
+		return KindUnreachable
+	case errors.Is(err, os.ErrPermission):
+		return KindPermission
+	case errors.As(err, &certErr), errors.As(err, &hostErr), errors.As(err, &authErr), errors.As(err, &recordErr):
+		return KindTLS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return KindTimeout
+	}
+	return KindUnknown
+}
+
+// probeTimeout 超时时间，默认1500毫秒
+func probeTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return defaultProbeTimeout
+	}
+	return timeout
+}
+
+// ctxErr ctx 结束导致连接关闭时返回 ctx 的错误
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,165 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// closedAddr 没有监听的本机地址
+func closedAddr(t *testing.T, network string) string {
+	t.Helper()
+	if network == "udp" {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := conn.LocalAddr().String()
+		conn.Close()
+		return addr
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "成功", err: nil, want: KindNone},
+		{name: "取消", err: fmt.Errorf("dial: %w", context.Canceled), want: KindCanceled},
+		{name: "超时", err: context.DeadlineExceeded, want: KindTimeout},
+		{name: "没有响应", err: ErrNoReply, want: KindTimeout},
+		{name: "拒绝连接", err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, want: KindRefused},
+		{name: "主机不可达", err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}, want: KindUnreachable},
+		{name: "域名解析", err: &net.DNSError{Err: "no such host", Name: "x.invalid"}, want: KindDNS},
+		{name: "权限", err: os.NewSyscallError("socket", syscall.EPERM), want: KindPermission},
+		{name: "状态码", err: fmt.Errorf("%w: 500", ErrStatusCode), want: KindStatus},
+		{name: "其他", err: errors.New("other"), want: KindUnknown},
+	}
+	for _, tt := range tests {
+		t.Run("测试错误分类:"+tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) want %q but get %q", tt.err, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTCPProber(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	p := &TCPProber{}
+	t.Run("测试 tcp 端口可用", func(t *testing.T) {
+		if r := p.Probe(context.Background(), ln.Addr().String()); !r.Up || r.Kind != KindNone || r.Latency <= 0 {
+			t.Errorf("Probe() get %+v", r)
+		}
+	})
+	t.Run("测试 tcp 端口未监听", func(t *testing.T) {
+		if r := p.Probe(context.Background(), closedAddr(t, "tcp")); r.Up || r.Kind != KindRefused {
+			t.Errorf("Probe() want %s but get %+v", KindRefused, r)
+		}
+	})
+}
+
+func TestUDPProber(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			conn.WriteTo(buf[:n], addr)
+		}
+	}()
+	t.Run("测试 udp echo", func(t *testing.T) {
+		p := &UDPProber{}
+		if r := p.Probe(context.Background(), conn.LocalAddr().String()); !r.Up {
+			t.Errorf("Probe() get %+v", r)
+		}
+	})
+	t.Run("测试 udp 响应错误", func(t *testing.T) {
+		p := &UDPProber{Expect: []byte("pong")}
+		if r := p.Probe(context.Background(), conn.LocalAddr().String()); r.Up || r.Kind != KindBody {
+			t.Errorf("Probe() want %s but get %+v", KindBody, r)
+		}
+	})
+	t.Run("测试 udp 端口未监听", func(t *testing.T) {
+		p := &UDPProber{Timeout: 500 * time.Millisecond}
+		if r := p.Probe(context.Background(), closedAddr(t, "udp")); r.Up || r.Kind != KindRefused {
+			t.Errorf("Probe() want %s but get %+v", KindRefused, r)
+		}
+	})
+}
+
+func TestHTTPProber(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	tests := []struct {
+		name   string
+		prober *HTTPProber
+		path   string
+		want   ErrorKind
+	}{
+		{name: "成功", prober: &HTTPProber{Client: srv.Client(), ExpectBody: `"ok"`}, path: "/health", want: KindNone},
+		{name: "跳过证书校验", prober: &HTTPProber{InsecureSkipVerify: true}, path: "/health", want: KindNone},
+		{name: "证书错误", prober: &HTTPProber{}, path: "/health", want: KindTLS},
+		{name: "状态码", prober: &HTTPProber{Client: srv.Client()}, path: "/missing", want: KindStatus},
+		{name: "指定状态码", prober: &HTTPProber{Client: srv.Client(), ExpectStatus: http.StatusNotFound}, path: "/missing", want: KindNone},
+		{name: "响应内容", prober: &HTTPProber{Client: srv.Client(), ExpectBody: "down"}, path: "/health", want: KindBody},
+		{name: "超时", prober: &HTTPProber{Client: srv.Client(), Timeout: 50 * time.Millisecond}, path: "/slow", want: KindTimeout},
+	}
+	for _, tt := range tests {
+		t.Run("测试 http 探测:"+tt.name, func(t *testing.T) {
+			r := tt.prober.Probe(context.Background(), srv.URL+tt.path)
+			if r.Kind != tt.want || r.Up != (tt.want == KindNone) {
+				t.Errorf("Probe() want %q but get %+v", tt.want, r)
+			}
+		})
+	}
+}
+
+func TestICMPProber(t *testing.T) {
+	t.Run("测试 icmp 探测本机", func(t *testing.T) {
+		var p Prober = &ICMPProber{Options: Options{Count: 1, Privileged: true}}
+		r := p.Probe(context.Background(), "127.0.0.1")
+		if r.Kind == KindPermission {
+			t.Skip(r.Err)
+		}
+		if !r.Up || r.Stats == nil || r.Stats.PacketsRecv != 1 {
+			t.Errorf("Probe() get %+v", r)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+
+	"github.com/go-ping/ping"
+	"github.com/snowlyg/helper/i18n"
+)
+
+// Code ping 结果代码，可以用于程序判断，Report.Message 返回对应语言的提示
+type Code string
+
+const (
+	CodeOK               Code = "ok"                //全部收到响应
+	CodePartialLoss      Code = "partial_loss"      //部分丢包
+	CodeTimeout          Code = "timeout"           //没有收到响应
+	CodeEmptyAddress     Code = "empty_address"     //设备ip为空
+	CodeResolveFailed    Code = "resolve_failed"    //域名解析失败
+	CodePermissionDenied Code = "permission_denied" //没有权限
+	CodeError            Code = "error"             //其他错误,例如 ctx 取消
+)
+
+// Report ping 结果
+type Report struct {
+	Host  string
+	Code  Code
+	Stats *ping.Statistics
+	Err   error
+}
+
+// CheckHost ping 主机并返回结果代码
+// @param host IP 或者域名
+// @param opts 配置
+func CheckHost(ctx context.Context, host string, opts Options) *Report {
+	stats, err := Probe(ctx, host, opts)
+	return NewReport(host, stats, err)
+}
+
+// NewReport 根据 Probe 的返回值生成结果
+func NewReport(host string, stats *ping.Statistics, err error) *Report {
+	return &Report{Host: host, Code: codeOf(stats, err), Stats: stats, Err: err}
+}
+
+// Up 是否至少收到一个响应
+func (r *Report) Up() bool {
+	return r.Code == CodeOK || r.Code == CodePartialLoss
+}
+
+// Message 指定语言的提示
+func (r *Report) Message(lang i18n.Lang) string {
+	switch r.Code {
+	case CodeOK, CodeTimeout:
+		return i18n.Sprintf(lang, "ping.code."+string(r.Code), r.Host)
+	case CodePartialLoss:
+		return i18n.Sprintf(lang, "ping.code."+string(r.Code), r.Host, r.Stats.PacketLoss)
+	case CodeResolveFailed:
+		return i18n.Sprintf(lang, "ping.code."+string(r.Code), r.Host, i18n.Localize(r.Err, lang))
+	}
+	return i18n.Localize(r.Err, lang)
+}
+
+// String 当前语言的提示
+func (r *Report) String() string {
+	return r.Message(i18n.CurrentLang())
+}
+
+// codeOf 结果代码
+func codeOf(stats *ping.Statistics, err error) Code {
+	var (
+		dnsErr  *net.DNSError
+		addrErr *net.AddrError
+	)
+	switch {
+	case errors.Is(err, ErrHostEmpty):
+		return CodeEmptyAddress
+	case errors.As(err, &dnsErr), errors.As(err, &addrErr):
+		return CodeResolveFailed
+	case errors.Is(err, os.ErrPermission):
+		return CodePermissionDenied
+	case err != nil:
+		return CodeError
+	case stats == nil || stats.PacketsRecv == 0:
+		return CodeTimeout
+	case stats.PacketsRecv < stats.PacketsSent:
+		return CodePartialLoss
+	}
+	return CodeOK
+}
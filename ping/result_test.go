@@ -0,0 +1,84 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/go-ping/ping"
+	"github.com/snowlyg/helper/i18n"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats *ping.Statistics
+		err   error
+		want  Code
+	}{
+		{name: "全部响应", stats: &ping.Statistics{PacketsSent: 3, PacketsRecv: 3}, want: CodeOK},
+		{name: "部分丢包", stats: &ping.Statistics{PacketsSent: 3, PacketsRecv: 1}, want: CodePartialLoss},
+		{name: "没有响应", stats: &ping.Statistics{PacketsSent: 3}, want: CodeTimeout},
+		{name: "设备ip为空", err: ErrHostEmpty, want: CodeEmptyAddress},
+		{name: "域名解析失败", err: &net.DNSError{Err: "no such host", Name: "x.invalid"}, want: CodeResolveFailed},
+		{name: "没有权限", err: newPermissionError(true, nil), want: CodePermissionDenied},
+		{name: "取消", err: context.Canceled, want: CodeError},
+	}
+	for _, tt := range tests {
+		t.Run("测试结果代码:"+tt.name, func(t *testing.T) {
+			if got := codeOf(tt.stats, tt.err); got != tt.want {
+				t.Errorf("codeOf() want %s but get %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReportMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *Report
+		zh     string
+		en     string
+	}{
+		{name: "可以访问", report: NewReport("10.0.0.1", &ping.Statistics{PacketsSent: 3, PacketsRecv: 3}, nil), zh: "设备ip(10.0.0.1)可以访问", en: "device 10.0.0.1 is reachable"},
+		{name: "部分丢包", report: NewReport("10.0.0.1", &ping.Statistics{PacketsSent: 4, PacketsRecv: 3, PacketLoss: 25}, nil), zh: "设备ip(10.0.0.1)可以访问,丢包率25%", en: "device 10.0.0.1 is reachable with 25% packet loss"},
+		{name: "离线", report: NewReport("10.0.0.1", &ping.Statistics{PacketsSent: 3}, nil), zh: "设备(10.0.0.1)可能已离线或者网络不稳定", en: "device 10.0.0.1 may be offline or the network is unstable"},
+		{name: "设备ip为空", report: NewReport("", nil, ErrHostEmpty), zh: "设备ip为空，请检查设备是否绑定ip", en: "device IP is empty, check that the device has an IP bound"},
+		{name: "包装的错误", report: NewReport("", nil, errors.New("other")), zh: "other", en: "other"},
+	}
+	for _, tt := range tests {
+		t.Run("测试提示:"+tt.name, func(t *testing.T) {
+			if got := tt.report.Message(i18n.ZhCN); got != tt.zh {
+				t.Errorf("Message(zh-CN) want %s but get %s", tt.zh, got)
+			}
+			if got := tt.report.Message(i18n.EnUS); got != tt.en {
+				t.Errorf("Message(en-US) want %s but get %s", tt.en, got)
+			}
+		})
+	}
+	t.Run("测试权限错误翻译", func(t *testing.T) {
+		report := NewReport("10.0.0.1", nil, newPermissionError(false, nil))
+		if got := report.Message(i18n.EnUS); got != "ping permission denied, missing net.ipv4.ping_group_range, run sysctl -w net.ipv4.ping_group_range=\"0 2147483647\" to allow UDP ICMP: permission denied" {
+			t.Errorf("Message(en-US) get %s", got)
+		}
+	})
+}
+
+func TestGetPingMsgEmpty(t *testing.T) {
+	t.Run("测试设备ip为空", func(t *testing.T) {
+		ok, msg := GetPingMsg("")
+		if ok || msg != "设备ip为空，请检查设备是否绑定ip" {
+			t.Errorf("GetPingMsg() get %v %s", ok, msg)
+		}
+	})
+}
+
+func TestPingMsg(t *testing.T) {
+	t.Run("测试部分丢包提示", func(t *testing.T) {
+		report := NewReport("10.0.0.1", &ping.Statistics{PacketsSent: 4, PacketsRecv: 3, PacketLoss: 25}, nil)
+		if got := pingMsg(report); got != "设备ip(10.0.0.1)可以访问" {
+			t.Errorf("pingMsg() get %s", got)
+		}
+	})
+}
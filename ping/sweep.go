@@ -0,0 +1,182 @@
+package ping
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-ping/ping"
+	"github.com/snowlyg/helper/i18n"
+)
+
+var ErrCIDRTooLarge = i18n.NewError("ping.cidr_too_large")
+
+const (
+	defaultConcurrency = 64
+	maxCIDRBits        = 16 //网段最多65536个地址
+)
+
+// SweepOptions 批量 ping 配置
+type SweepOptions struct {
+	Options
+	Concurrency int //并发数,默认64
+}
+
+// SweepResult 单个主机的 ping 结果
+type SweepResult struct {
+	Host  string
+	Up    bool //至少收到一个响应
+	Stats *ping.Statistics
+	Err   error
+}
+
+// SweepSummary 批量 ping 汇总
+type SweepSummary struct {
+	Total    int
+	Up       int
+	Down     int
+	Duration time.Duration
+}
+
+// Sweeper 批量 ping，结果通过 Results 返回
+//
+//	s := ping.Sweep(ctx, hosts, ping.SweepOptions{Concurrency: 100})
+//	for r := range s.Results() {
+//		fmt.Println(r.Host, r.Up)
+//	}
+//	summary := s.Wait()
+type Sweeper struct {
+	results chan SweepResult
+	done    chan struct{}
+	mu      sync.Mutex
+	summary SweepSummary
+}
+
+// Sweep 并发 ping 多个主机，ctx 取消后未完成的主机返回 ctx 的错误
+// @param hosts IP 或者域名
+// @param opts 配置
+func Sweep(ctx context.Context, hosts []string, opts SweepOptions) *Sweeper {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if concurrency > len(hosts) && len(hosts) > 0 {
+		concurrency = len(hosts)
+	}
+	s := &Sweeper{
+		results: make(chan SweepResult, concurrency),
+		done:    make(chan struct{}),
+	}
+	start := time.Now()
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range jobs {
+				stats, err := Probe(ctx, host, opts.Options)
+				result := SweepResult{Host: host, Stats: stats, Err: err}
+				result.Up = err == nil && stats != nil && stats.PacketsRecv > 0
+				s.add(result)
+				s.results <- result
+			}
+		}()
+	}
+	go func() {
+		for _, host := range hosts {
+			jobs <- host
+		}
+		close(jobs)
+		wg.Wait()
+		s.mu.Lock()
+		s.summary.Duration = time.Since(start)
+		s.mu.Unlock()
+		close(s.results)
+		close(s.done)
+	}()
+	return s
+}
+
+// SweepCIDR 并发 ping 网段内的所有主机，不包括网络地址和广播地址
+// @param cidr 网段,例如 10.0.0.0/24
+func SweepCIDR(ctx context.Context, cidr string, opts SweepOptions) (*Sweeper, error) {
+	hosts, err := Hosts(cidr)
+	if err != nil {
+		return nil, err
+	}
+	return Sweep(ctx, hosts, opts), nil
+}
+
+// Results 每个主机的结果，全部完成后关闭
+func (s *Sweeper) Results() <-chan SweepResult {
+	return s.results
+}
+
+// Wait 等待全部完成并返回汇总，未读取的结果会被丢弃
+func (s *Sweeper) Wait() SweepSummary {
+	for range s.results {
+	}
+	<-s.done
+	return s.Summary()
+}
+
+// Summary 当前的汇总
+func (s *Sweeper) Summary() SweepSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary
+}
+
+// add 累计汇总
+func (s *Sweeper) add(result SweepResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summary.Total++
+	if result.Up {
+		s.summary.Up++
+	} else {
+		s.summary.Down++
+	}
+}
+
+// Hosts 网段内的主机地址，/31 和 /32 包括全部地址，其他不包括网络地址和广播地址
+// @param cidr 网段,例如 10.0.0.0/24
+func Hosts(cidr string) ([]string, error) {
+	ip, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ipnet.IP = ipnet.IP.To4()
+	}
+	ones, bits := ipnet.Mask.Size()
+	if bits-ones > maxCIDRBits {
+		return nil, fmt.Errorf("%w: %s", ErrCIDRTooLarge, cidr)
+	}
+	count := 1 << uint(bits-ones)
+	hosts := make([]string, 0, count)
+	cur := make(net.IP, len(ipnet.IP))
+	copy(cur, ipnet.IP)
+	for i := 0; i < count; i++ {
+		hosts = append(hosts, cur.String())
+		incIP(cur)
+	}
+	// IPv4 去掉网络地址和广播地址
+	if bits == 32 && count > 2 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+	return hosts, nil
+}
+
+// incIP 地址加一
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			return
+		}
+	}
+}
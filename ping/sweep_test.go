@@ -0,0 +1,80 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHosts(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want []string
+	}{
+		{cidr: "10.0.0.0/30", want: []string{"10.0.0.1", "10.0.0.2"}},
+		{cidr: "10.0.0.5/31", want: []string{"10.0.0.4", "10.0.0.5"}},
+		{cidr: "10.0.0.5/32", want: []string{"10.0.0.5"}},
+		{cidr: "fd00::/127", want: []string{"fd00::", "fd00::1"}},
+	}
+	for _, tt := range tests {
+		t.Run("测试网段地址:"+tt.cidr, func(t *testing.T) {
+			got, err := Hosts(tt.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hosts() want %v but get %v", tt.want, got)
+			}
+		})
+	}
+	t.Run("测试网段过大", func(t *testing.T) {
+		if _, err := Hosts("10.0.0.0/8"); !errors.Is(err, ErrCIDRTooLarge) {
+			t.Errorf("Hosts() want %v but get %v", ErrCIDRTooLarge, err)
+		}
+	})
+	t.Run("测试网段错误", func(t *testing.T) {
+		if _, err := Hosts("10.0.0.0"); err == nil {
+			t.Error("Hosts() want error")
+		}
+	})
+}
+
+func TestSweep(t *testing.T) {
+	opts := SweepOptions{Options: Options{Count: 1, Interval: 100 * time.Millisecond, Privileged: true}, Concurrency: 2}
+	t.Run("测试批量 ping 本机", func(t *testing.T) {
+		s, err := SweepCIDR(context.Background(), "127.0.0.0/30", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]bool{}
+		for r := range s.Results() {
+			if errors.Is(r.Err, os.ErrPermission) {
+				t.Skip(r.Err.Error())
+			}
+			got[r.Host] = r.Up
+		}
+		want := map[string]bool{"127.0.0.1": true, "127.0.0.2": true}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Sweep() want %v but get %v", want, got)
+		}
+		if summary := s.Wait(); summary.Total != 2 || summary.Up != 2 || summary.Down != 0 {
+			t.Errorf("Wait() get %+v", summary)
+		}
+	})
+	t.Run("测试取消", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		s := Sweep(ctx, []string{"127.0.0.1", "127.0.0.2", "127.0.0.3"}, opts)
+		for r := range s.Results() {
+			if !errors.Is(r.Err, context.Canceled) {
+				t.Errorf("Sweep() %s want %v but get %v", r.Host, context.Canceled, r.Err)
+			}
+		}
+		if summary := s.Wait(); summary.Total != 3 || summary.Down != 3 {
+			t.Errorf("Wait() get %+v", summary)
+		}
+	})
+}
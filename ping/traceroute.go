@@ -0,0 +1,273 @@
+package ping
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/snowlyg/helper/sys"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// traceID 每次 traceroute 使用不同的 icmp id，同一进程中可以并发执行
+var traceID uint32
+
+// TraceOptions traceroute 配置
+type TraceOptions struct {
+	MaxHops int           //最大跳数,默认30
+	Probes  int           //每一跳的探测次数,默认3
+	Timeout time.Duration //每次探测的超时时间,默认1秒
+	Size    int           //数据大小,默认32
+	Family  sys.IPFamily  //域名解析的地址类型,默认 IPv4
+}
+
+// withDefaults 使用默认配置补全零值字段
+func (o TraceOptions) withDefaults() TraceOptions {
+	if o.MaxHops <= 0 {
+		o.MaxHops = 30
+	}
+	if o.Probes <= 0 {
+		o.Probes = 3
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = time.Second
+	}
+	if o.Size <= 0 {
+		o.Size = 32
+	}
+	return o
+}
+
+// Hop traceroute 的一跳
+type Hop struct {
+	TTL         int
+	Addr        net.IP          //响应地址,全部超时时为 nil
+	RTTs        []time.Duration //收到响应的往返时间
+	Timeouts    int             //超时次数
+	Unreachable bool            //收到目标不可达
+}
+
+// Trace traceroute 结果
+type Trace struct {
+	Target  string
+	Addr    net.IP //目标地址
+	Hops    []Hop
+	Reached bool //是否到达目标
+}
+
+// String 类似 traceroute 命令的输出
+func (t *Trace) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "traceroute to %s (%s), %d hops\n", t.Target, t.Addr, len(t.Hops))
+	for _, hop := range t.Hops {
+		fmt.Fprintf(&b, "%2d  ", hop.TTL)
+		if hop.Addr == nil {
+			b.WriteString("*")
+		} else {
+			b.WriteString(hop.Addr.String())
+		}
+		for _, rtt := range hop.RTTs {
+			fmt.Fprintf(&b, "  %.3f ms", float64(rtt)/float64(time.Millisecond))
+		}
+		b.WriteString(strings.Repeat("  *", hop.Timeouts))
+		if hop.Unreachable {
+			b.WriteString("  !")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Traceroute 使用 ttl 递增的 icmp echo 探测到目标的路径，需要 raw socket 权限
+// 到达目标、收到目标不可达或者达到最大跳数后返回，ctx 取消时返回已探测的跳和 ctx 的错误
+// @param host IP 或者域名
+// @param opts 配置
+func Traceroute(ctx context.Context, host string, opts TraceOptions) (*Trace, error) {
+	if host == "" {
+		return nil, ErrHostEmpty
+	}
+	opts = opts.withDefaults()
+	ipaddr, err := resolve(ctx, host, opts.Family)
+	if err != nil {
+		return nil, err
+	}
+	tracer, err := newTracer(ipaddr.IP)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, newRawPermissionError(err)
+		}
+		return nil, err
+	}
+	defer tracer.conn.Close()
+
+	trace := &Trace{Target: host, Addr: ipaddr.IP}
+	payload := make([]byte, opts.Size)
+	seq := 0
+	for ttl := 1; ttl <= opts.MaxHops; ttl++ {
+		hop := Hop{TTL: ttl}
+		done := false
+		for i := 0; i < opts.Probes; i++ {
+			if err := ctx.Err(); err != nil {
+				return trace, err
+			}
+			seq++
+			reply, err := tracer.probe(ctx, ttl, seq, payload, opts.Timeout)
+			if err != nil {
+				return trace, err
+			}
+			if reply == nil {
+				hop.Timeouts++
+				continue
+			}
+			if hop.Addr == nil {
+				hop.Addr = reply.addr
+			}
+			hop.RTTs = append(hop.RTTs, reply.rtt)
+			switch reply.kind {
+			case replyEcho:
+				trace.Reached = true
+				done = true
+			case replyUnreachable:
+				hop.Unreachable = true
+				done = true
+			}
+		}
+		trace.Hops = append(trace.Hops, hop)
+		if done {
+			break
+		}
+	}
+	return trace, nil
+}
+
+// replyKind 响应类型
+type replyKind int
+
+const (
+	replyTimeExceeded replyKind = iota //中间路由 ttl 超时
+	replyEcho                          //目标响应
+	replyUnreachable                   //目标不可达
+)
+
+// traceReply 一次探测的响应
+type traceReply struct {
+	kind replyKind
+	addr net.IP
+	rtt  time.Duration
+}
+
+// tracer 使用一个 raw socket 发送 echo 并接收响应
+type tracer struct {
+	conn  *icmp.PacketConn
+	dst   net.Addr
+	ipv6  bool
+	id    int
+	proto int
+}
+
+// newTracer 创建 raw socket
+func newTracer(ip net.IP) (*tracer, error) {
+	t := &tracer{dst: &net.IPAddr{IP: ip}, id: int(atomic.AddUint32(&traceID, 1)+uint32(os.Getpid())) & 0xffff}
+	var err error
+	if ip.To4() != nil {
+		t.proto = 1
+		t.conn, err = icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	} else {
+		t.ipv6, t.proto = true, 58
+		t.conn, err = icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	}
+	return t, err
+}
+
+// probe 使用指定 ttl 发送一次 echo，超时时返回 nil
+func (t *tracer) probe(ctx context.Context, ttl, seq int, payload []byte, timeout time.Duration) (*traceReply, error) {
+	msg := icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: t.id, Seq: seq & 0xffff, Data: payload}}
+	var err error
+	if t.ipv6 {
+		msg.Type = ipv6.ICMPTypeEchoRequest
+		err = t.conn.IPv6PacketConn().SetHopLimit(ttl)
+	} else {
+		err = t.conn.IPv4PacketConn().SetTTL(ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	deadline := start.Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := t.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if _, err := t.conn.WriteTo(b, t.dst); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := t.conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		kind, ok := matchReply(t.proto, buf[:n], t.id, seq&0xffff)
+		if !ok {
+			// 其他进程的 icmp 或者自己发送的 echo
+			continue
+		}
+		addr := peer.(*net.IPAddr).IP
+		return &traceReply{kind: kind, addr: addr, rtt: time.Since(start)}, nil
+	}
+}
+
+// matchReply 解析 icmp 响应，判断是否是 id 和 seq 对应的 echo 的响应
+// @param proto 1 为 icmp,58 为 icmpv6
+func matchReply(proto int, b []byte, id, seq int) (replyKind, bool) {
+	msg, err := icmp.ParseMessage(proto, b)
+	if err != nil {
+		return 0, false
+	}
+	var data []byte
+	var kind replyKind
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		if msg.Type != ipv4.ICMPTypeEchoReply && msg.Type != ipv6.ICMPTypeEchoReply {
+			return 0, false
+		}
+		return replyEcho, body.ID == id && body.Seq == seq
+	case *icmp.TimeExceeded:
+		data, kind = body.Data, replyTimeExceeded
+	case *icmp.DstUnreach:
+		data, kind = body.Data, replyUnreachable
+	default:
+		return 0, false
+	}
+	// 错误报文中包含原始的 ip 头和 icmp 头的前8个字节
+	headerLen := ipv6.HeaderLen
+	if proto == 1 {
+		if len(data) < ipv4.HeaderLen {
+			return 0, false
+		}
+		headerLen = int(data[0]&0x0f) << 2
+	}
+	if len(data) < headerLen+8 {
+		return 0, false
+	}
+	inner := data[headerLen:]
+	return kind, int(binary.BigEndian.Uint16(inner[4:6])) == id && int(binary.BigEndian.Uint16(inner[6:8])) == seq
+}
@@ -0,0 +1,93 @@
+package ping
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// timeExceeded 模拟路由返回的 ttl 超时报文
+func timeExceeded(t *testing.T, id, seq int) []byte {
+	t.Helper()
+	echo, err := (&icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: id, Seq: seq}}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := make([]byte, ipv4.HeaderLen)
+	header[0] = 0x45
+	binary.BigEndian.PutUint16(header[2:4], uint16(len(header)+len(echo)))
+	header[9] = 1
+	b, err := (&icmp.Message{Type: ipv4.ICMPTypeTimeExceeded, Body: &icmp.TimeExceeded{Data: append(header, echo[:8]...)}}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMatchReply(t *testing.T) {
+	reply, err := (&icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{ID: 7, Seq: 3}}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := (&icmp.Message{Type: ipv4.ICMPTypeEcho, Body: &icmp.Echo{ID: 7, Seq: 3}}).Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		b    []byte
+		kind replyKind
+		ok   bool
+	}{
+		{name: "echo 响应", b: reply, kind: replyEcho, ok: true},
+		{name: "自己发送的 echo", b: request, ok: false},
+		{name: "ttl 超时", b: timeExceeded(t, 7, 3), kind: replyTimeExceeded, ok: true},
+		{name: "其他 id 的 ttl 超时", b: timeExceeded(t, 8, 3), kind: replyTimeExceeded, ok: false},
+		{name: "报文错误", b: []byte{11, 0}, ok: false},
+	}
+	for _, tt := range tests {
+		t.Run("测试解析响应:"+tt.name, func(t *testing.T) {
+			kind, ok := matchReply(1, tt.b, 7, 3)
+			if ok != tt.ok || (ok && kind != tt.kind) {
+				t.Errorf("matchReply() want %v %v but get %v %v", tt.kind, tt.ok, kind, ok)
+			}
+		})
+	}
+}
+
+func TestTraceroute(t *testing.T) {
+	opts := TraceOptions{MaxHops: 3, Probes: 2, Timeout: 500 * time.Millisecond}
+	for _, host := range []string{"127.0.0.1", "::1"} {
+		t.Run("测试 traceroute 本机:"+host, func(t *testing.T) {
+			trace, err := Traceroute(context.Background(), host, opts)
+			if errors.Is(err, os.ErrPermission) {
+				t.Skip(err.Error())
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !trace.Reached || len(trace.Hops) != 1 {
+				t.Fatalf("Traceroute() get %+v", trace)
+			}
+			hop := trace.Hops[0]
+			if !hop.Addr.Equal(trace.Addr) || len(hop.RTTs) != 2 || hop.Timeouts != 0 {
+				t.Errorf("Traceroute() hop get %+v", hop)
+			}
+			if out := trace.String(); !strings.Contains(out, " 1  "+host) {
+				t.Errorf("String() get %s", out)
+			}
+		})
+	}
+	t.Run("测试设备ip为空", func(t *testing.T) {
+		if _, err := Traceroute(context.Background(), "", opts); !errors.Is(err, ErrHostEmpty) {
+			t.Errorf("Traceroute() want %v but get %v", ErrHostEmpty, err)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package sys
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var ErrNoComponent = i18n.NewError("sys.no_component")
+
+// collectComponents 读取硬件信息，测试时替换
+var collectComponents = CollectComponents
+
+// 设备指纹组成部分
+const (
+	ComponentMachineID     = "machine_id"     //systemd machine-id
+	ComponentProductUUID   = "product_uuid"   //DMI 主板 UUID
+	ComponentProductSerial = "product_serial" //DMI 序列号
+	ComponentMAC           = "mac"            //主接口 MAC 地址
+	ComponentDisks         = "disks"          //非移动硬盘的序列号
+	ComponentHostname      = "hostname"       //没有其他信息时使用
+)
+
+// DefaultWeights 组成部分的默认权重
+var DefaultWeights = map[string]int{
+	ComponentMachineID:     3,
+	ComponentProductUUID:   3,
+	ComponentProductSerial: 2,
+	ComponentDisks:         2,
+	ComponentMAC:           1,
+	ComponentHostname:      1,
+}
+
+// Component 设备指纹的组成部分
+type Component struct {
+	Name  string
+	Value string
+}
+
+// Fingerprint 设备指纹，Components 只保存哈希值
+type Fingerprint struct {
+	ID         string            `json:"id"`
+	Components map[string]string `json:"components"` //组成部分名称和 sha256
+	Weights    map[string]int    `json:"weights"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	Score      float64           `json:"-"` //本次和保存的指纹的相似度,0到1,新生成时为1
+	Changed    []string          `json:"-"` //本次发生变化的组成部分
+}
+
+// FingerprintOptions 设备指纹配置
+type FingerprintOptions struct {
+	Path      string         //保存路径,默认 DefaultFingerprintPath
+	Threshold float64        //相似度达到后沿用保存的 ID,默认0.6
+	Weights   map[string]int //组成部分权重,默认 DefaultWeights
+	Policy    PrimaryPolicy  //选择主接口 MAC 的规则
+}
+
+// DefaultFingerprintPath 默认保存路径，root 用户为 /var/lib/helper/device-id.json，其他用户在配置目录中
+func DefaultFingerprintPath() string {
+	if os.Geteuid() == 0 {
+		return "/var/lib/helper/device-id.json"
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "helper", "device-id.json")
+}
+
+// DeviceID 生成稳定的设备ID
+// 读取保存的指纹，按权重计算和当前硬件信息的相似度，达到 Threshold 时沿用保存的 ID 并更新变化的组成部分，
+// 否则根据当前硬件信息生成新的 ID，结果会保存到文件
+// @param opts 配置
+func DeviceID(opts FingerprintOptions) (*Fingerprint, error) {
+	if opts.Path == "" {
+		opts.Path = DefaultFingerprintPath()
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = 0.6
+	}
+	if opts.Weights == nil {
+		opts.Weights = DefaultWeights
+	}
+	components := collectComponents(opts.Policy)
+	if len(components) == 0 {
+		return nil, ErrNoComponent
+	}
+	current := make(map[string]string, len(components))
+	for _, c := range components {
+		if opts.Weights[c.Name] > 0 {
+			current[c.Name] = hashString(c.Value)
+		}
+	}
+	if len(current) == 0 {
+		return nil, ErrNoComponent
+	}
+
+	now := time.Now()
+	saved, err := loadFingerprint(opts.Path)
+	if err == nil && saved.ID != "" {
+		score, changed := compareComponents(saved, current)
+		if score >= opts.Threshold {
+			saved.Score, saved.Changed = score, changed
+			if len(changed) > 0 {
+				saved.Components, saved.Weights, saved.UpdatedAt = current, weightsOf(current, opts.Weights), now
+				if err := saveFingerprint(opts.Path, saved); err != nil {
+					return saved, err
+				}
+			}
+			return saved, nil
+		}
+	}
+
+	fp := &Fingerprint{
+		ID:         fingerprintID(current),
+		Components: current,
+		Weights:    weightsOf(current, opts.Weights),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Score:      1,
+	}
+	if saved != nil && saved.ID != "" {
+		fp.Score, fp.Changed = compareComponents(saved, current)
+	}
+	return fp, saveFingerprint(opts.Path, fp)
+}
+
+// CollectComponents 读取当前的硬件信息，值为空的部分不返回
+func CollectComponents(policy PrimaryPolicy) []Component {
+	components := platformComponents()
+	if mac := PrimaryMAC(policy); mac != "" {
+		components = append(components, Component{Name: ComponentMAC, Value: strings.ToLower(mac)})
+	}
+	if len(components) == 0 {
+		if hostname, err := os.Hostname(); err == nil && hostname != "" {
+			components = append(components, Component{Name: ComponentHostname, Value: hostname})
+		}
+	}
+	return components
+}
+
+// compareComponents 按保存的权重计算相似度，返回变化的组成部分
+func compareComponents(saved *Fingerprint, current map[string]string) (float64, []string) {
+	total, matched := 0, 0
+	var changed []string
+	for name, hash := range saved.Components {
+		weight := saved.Weights[name]
+		if weight <= 0 {
+			weight = 1
+		}
+		total += weight
+		if current[name] == hash {
+			matched += weight
+		} else {
+			changed = append(changed, name)
+		}
+	}
+	for name := range current {
+		if _, ok := saved.Components[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	if total == 0 {
+		return 0, changed
+	}
+	return float64(matched) / float64(total), changed
+}
+
+// fingerprintID 根据组成部分的哈希生成 ID
+func fingerprintID(components map[string]string) string {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name + "=" + components[name] + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// weightsOf 组成部分使用的权重
+func weightsOf(components map[string]string, weights map[string]int) map[string]int {
+	w := make(map[string]int, len(components))
+	for name := range components {
+		w[name] = weights[name]
+	}
+	return w
+}
+
+// hashString sha256
+func hashString(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadFingerprint 读取保存的指纹
+func loadFingerprint(path string) (*Fingerprint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fp := &Fingerprint{}
+	if err := json.Unmarshal(b, fp); err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
+
+// saveFingerprint 保存指纹，先写临时文件再重命名
+func saveFingerprint(path string, fp *Fingerprint) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(fp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readTrim 读取文件并去掉空白，失败时返回空
+func readTrim(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,67 @@
+package sys
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dmi 中没有意义的默认值
+var invalidDMI = map[string]bool{
+	"":                                     true,
+	"0":                                    true,
+	"none":                                 true,
+	"default string":                       true,
+	"to be filled by o.e.m.":               true,
+	"not specified":                        true,
+	"system serial number":                 true,
+	"03000200-0400-0500-0006-000700080009": true,
+	"00000000-0000-0000-0000-000000000000": true,
+}
+
+// platformComponents 读取 machine-id、/sys/class/dmi/id 和 /sys/block 中的硬件信息
+func platformComponents() []Component {
+	var components []Component
+	add := func(name, value string) {
+		if !invalidDMI[strings.ToLower(value)] {
+			components = append(components, Component{Name: name, Value: value})
+		}
+	}
+	machineID := readTrim("/etc/machine-id")
+	if machineID == "" {
+		machineID = readTrim("/var/lib/dbus/machine-id")
+	}
+	add(ComponentMachineID, machineID)
+	add(ComponentProductUUID, strings.ToLower(readTrim("/sys/class/dmi/id/product_uuid")))
+	add(ComponentProductSerial, readTrim("/sys/class/dmi/id/product_serial"))
+	add(ComponentDisks, strings.Join(diskSerials(), ","))
+	return components
+}
+
+// diskSerials 非移动硬盘的序列号，按序列号排序
+func diskSerials() []string {
+	devices, err := os.ReadDir("/sys/block")
+	if err != nil {
+		return nil
+	}
+	var serials []string
+	for _, device := range devices {
+		name := device.Name()
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") || strings.HasPrefix(name, "zram") || strings.HasPrefix(name, "dm-") {
+			continue
+		}
+		dir := filepath.Join("/sys/block", name)
+		if readTrim(filepath.Join(dir, "removable")) == "1" {
+			continue
+		}
+		for _, file := range []string{"device/serial", "serial", "device/wwid", "wwid"} {
+			if serial := readTrim(filepath.Join(dir, file)); serial != "" {
+				serials = append(serials, serial)
+				break
+			}
+		}
+	}
+	sort.Strings(serials)
+	return serials
+}
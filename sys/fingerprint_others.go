@@ -0,0 +1,8 @@
+//go:build !linux
+
+package sys
+
+// platformComponents 其他系统只使用 MAC 地址，没有时使用主机名
+func platformComponents() []Component {
+	return nil
+}
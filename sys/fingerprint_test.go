@@ -0,0 +1,127 @@
+package sys
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+// fakeComponents 替换硬件信息
+func fakeComponents(t *testing.T, components map[string]string) {
+	t.Helper()
+	prev := collectComponents
+	t.Cleanup(func() { collectComponents = prev })
+	collectComponents = func(PrimaryPolicy) []Component {
+		var list []Component
+		for name, value := range components {
+			list = append(list, Component{Name: name, Value: value})
+		}
+		return list
+	}
+}
+
+// testComponents 测试硬件信息，默认权重合计11
+func testComponents() map[string]string {
+	return map[string]string{
+		ComponentMachineID:     "4c4c4544003",
+		ComponentProductUUID:   "03000200-0400-0500-0006-000700080009",
+		ComponentProductSerial: "SN0001",
+		ComponentDisks:         "WD-0001",
+		ComponentMAC:           "00:16:3e:00:00:01",
+	}
+}
+
+func TestDeviceID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "helper", "device-id.json")
+	opts := FingerprintOptions{Path: path}
+	components := testComponents()
+	fakeComponents(t, components)
+	first, err := DeviceID(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == "" || first.Score != 1 || len(first.Changed) != 0 {
+		t.Fatalf("DeviceID() get %+v", first)
+	}
+
+	t.Run("测试读取保存的指纹", func(t *testing.T) {
+		saved, err := loadFingerprint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.ID != first.ID || !reflect.DeepEqual(saved.Components, first.Components) {
+			t.Errorf("loadFingerprint() get %+v", saved)
+		}
+		fp, err := DeviceID(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fp.ID != first.ID || fp.Score != 1 || len(fp.Changed) != 0 {
+			t.Errorf("DeviceID() reload want %s but get %+v", first.ID, fp)
+		}
+	})
+	t.Run("测试一个组成部分变化沿用ID", func(t *testing.T) {
+		components[ComponentMAC] = "00:16:3e:00:00:02"
+		fp, err := DeviceID(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fp.ID != first.ID {
+			t.Errorf("DeviceID() want %s but get %s", first.ID, fp.ID)
+		}
+		if !reflect.DeepEqual(fp.Changed, []string{ComponentMAC}) || fp.Score != 10.0/11.0 {
+			t.Errorf("DeviceID() changed %v score %v", fp.Changed, fp.Score)
+		}
+		// 变化的组成部分已经保存
+		saved, err := loadFingerprint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.ID != first.ID || saved.Components[ComponentMAC] != hashString("00:16:3e:00:00:02") {
+			t.Errorf("DeviceID() not saved %+v", saved)
+		}
+	})
+	t.Run("测试大部分组成部分变化生成新ID", func(t *testing.T) {
+		components[ComponentMachineID] = "new-machine-id"
+		components[ComponentProductUUID] = "new-uuid"
+		components[ComponentProductSerial] = "SN0002"
+		fp, err := DeviceID(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fp.ID == first.ID || fp.Score >= 0.6 {
+			t.Errorf("DeviceID() want new id but get %+v", fp)
+		}
+		saved, err := loadFingerprint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.ID != fp.ID {
+			t.Errorf("DeviceID() saved %s want %s", saved.ID, fp.ID)
+		}
+	})
+}
+
+func TestDeviceIDNoComponent(t *testing.T) {
+	opts := FingerprintOptions{Path: filepath.Join(t.TempDir(), "device-id.json")}
+	t.Run("测试没有硬件信息", func(t *testing.T) {
+		fakeComponents(t, nil)
+		if _, err := DeviceID(opts); !errors.Is(err, ErrNoComponent) {
+			t.Errorf("DeviceID() want %v but get %v", ErrNoComponent, err)
+		}
+	})
+	t.Run("测试硬件信息权重为0", func(t *testing.T) {
+		fakeComponents(t, map[string]string{"unknown": "value"})
+		if _, err := DeviceID(opts); !errors.Is(err, ErrNoComponent) {
+			t.Errorf("DeviceID() want %v but get %v", ErrNoComponent, err)
+		}
+	})
+	t.Run("测试错误信息翻译", func(t *testing.T) {
+		if got := i18n.Localize(ErrNoComponent, i18n.EnUS); got != "no hardware information available for the device ID" {
+			t.Errorf("Localize(en-US) get %s", got)
+		}
+	})
+}
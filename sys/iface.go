@@ -0,0 +1,285 @@
+package sys
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+var ErrNoInterface = i18n.NewError("sys.no_interface")
+
+// listInterfaces 获取网络接口，测试时替换
+var listInterfaces = Interfaces
+
+// 接口类型
+const (
+	KindLoopback = "loopback"
+	KindEthernet = "ethernet"
+	KindWireless = "wireless"
+	KindDocker   = "docker"
+	KindVeth     = "veth"
+	KindBridge   = "bridge"
+	KindTun      = "tun"
+	KindVirtual  = "virtual" //其他虚拟接口
+)
+
+// virtualPrefixes 虚拟接口名称前缀和类型，没有 /sys/class/net 时按名称判断
+var virtualPrefixes = []struct {
+	prefix string
+	kind   string
+}{
+	{"docker", KindDocker},
+	{"veth", KindVeth},
+	{"br-", KindBridge},
+	{"virbr", KindBridge},
+	{"cni", KindBridge},
+	{"tun", KindTun},
+	{"tap", KindTun},
+	{"utun", KindTun},
+	{"wg", KindTun},
+	{"w-", KindVirtual},
+	{"vmnet", KindVirtual},
+	{"vboxnet", KindVirtual},
+	{"flannel", KindVirtual},
+	{"cali", KindVirtual},
+	{"ifb", KindVirtual},
+	{"dummy", KindVirtual},
+}
+
+// Addr 接口地址
+type Addr struct {
+	IP        net.IP
+	PrefixLen int
+}
+
+// String 例如 10.0.0.1/24
+func (a Addr) String() string {
+	return a.IP.String() + "/" + strconv.Itoa(a.PrefixLen)
+}
+
+// Interface 网络接口
+type Interface struct {
+	Index        int
+	Name         string
+	HardwareAddr net.HardwareAddr
+	MTU          int
+	Flags        net.Flags
+	IPv4         []Addr
+	IPv6         []Addr
+	Speed        int    //速率,Mbps,未知时为-1
+	OperState    string //up,down,unknown,dormant 等,没有 /sys/class/net 时根据 Flags 判断
+	Kind         string //loopback,ethernet,wireless,docker,veth,bridge,tun,virtual
+	Virtual      bool   //是否是虚拟接口
+}
+
+// MAC MAC 地址，例如 00:16:3e:00:00:01
+func (i *Interface) MAC() string {
+	return i.HardwareAddr.String()
+}
+
+// Up 接口是否启用
+func (i *Interface) Up() bool {
+	return i.Flags&net.FlagUp != 0
+}
+
+// Loopback 是否是回环接口
+func (i *Interface) Loopback() bool {
+	return i.Flags&net.FlagLoopback != 0
+}
+
+// Addrs 指定类型的地址，IPDual 时 IPv4 在前
+func (i *Interface) Addrs(family IPFamily) []Addr {
+	switch family {
+	case IPv6:
+		return i.IPv6
+	case IPDual:
+		return append(append([]Addr{}, i.IPv4...), i.IPv6...)
+	default:
+		return i.IPv4
+	}
+}
+
+// Interfaces 所有网络接口，按 Index 排序
+// linux 从 /sys/class/net 读取速率、状态和类型
+func Interfaces() ([]Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Interface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		item := Interface{
+			Index:        iface.Index,
+			Name:         iface.Name,
+			HardwareAddr: iface.HardwareAddr,
+			MTU:          iface.MTU,
+			Flags:        iface.Flags,
+			Speed:        -1,
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ones, _ := ipnet.Mask.Size()
+			if ip4 := ipnet.IP.To4(); ip4 != nil {
+				item.IPv4 = append(item.IPv4, Addr{IP: ip4, PrefixLen: ones})
+			} else {
+				item.IPv6 = append(item.IPv6, Addr{IP: ipnet.IP, PrefixLen: ones})
+			}
+		}
+		readLinkInfo(&item)
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Index < list[j].Index })
+	return list, nil
+}
+
+// kindByName 根据名称判断接口类型
+func kindByName(iface *Interface) (kind string, virtual bool) {
+	if iface.Loopback() {
+		return KindLoopback, true
+	}
+	for _, p := range virtualPrefixes {
+		if strings.HasPrefix(iface.Name, p.prefix) {
+			return p.kind, true
+		}
+	}
+	if strings.HasPrefix(iface.Name, "wl") || strings.Contains(iface.Name, "WLAN") || strings.Contains(iface.Name, "Wi-Fi") {
+		return KindWireless, false
+	}
+	return KindEthernet, false
+}
+
+// DefaultPrimaryNames 默认的主接口名称，按顺序匹配
+var DefaultPrimaryNames = []string{`^eth0$`, `^ens\d*`, `^enp`, `^eno`, `^eth\d*`, `^(以太网|Ethernet)`, `^en\d+$`, `^wlan\d*`, `^waln\d*`, `^wl`, `^(WLAN|Wi-Fi)`}
+
+// PrimaryPolicy 选择主接口的规则，零值为默认规则
+type PrimaryPolicy struct {
+	Names          []string //接口名称正则,按顺序优先匹配,默认 DefaultPrimaryNames,都不匹配时使用第一个符合条件的接口
+	Exclude        []string //排除的接口名称正则
+	AllowVirtual   bool     //允许虚拟接口
+	AllowDown      bool     //允许未启用的接口
+	RequireIP      bool     //必须有 Family 类型的地址
+	Family         IPFamily //RequireIP 和 PreferOutbound 的地址类型
+	PreferOutbound bool     //优先使用访问外网的地址所在的接口
+	RequireMAC     bool     //必须有 MAC 地址
+}
+
+// PrimaryInterface 按规则选择主接口，回环接口不会被选择
+func PrimaryInterface(policy PrimaryPolicy) (*Interface, error) {
+	ifaces, err := listInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	outbound := ""
+	if policy.PreferOutbound {
+		outbound = OutboundIP(policy.Family)
+	}
+	return SelectPrimary(ifaces, policy, outbound)
+}
+
+// PrimaryMAC 主接口的 MAC 地址，没有时返回空
+func PrimaryMAC(policy PrimaryPolicy) string {
+	policy.RequireMAC = true
+	iface, err := PrimaryInterface(policy)
+	if err != nil {
+		return ""
+	}
+	return iface.MAC()
+}
+
+// SelectPrimary 从接口中按规则选择主接口
+// @param outbound 访问外网使用的地址,不为空时优先选择该地址所在的接口
+func SelectPrimary(ifaces []Interface, policy PrimaryPolicy, outbound string) (*Interface, error) {
+	names := policy.Names
+	if len(names) == 0 {
+		names = DefaultPrimaryNames
+	}
+	include, err := compileAll(names)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileAll(policy.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Interface
+	for i := range ifaces {
+		iface := &ifaces[i]
+		if policy.eligible(iface, exclude) {
+			candidates = append(candidates, iface)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoInterface
+	}
+	if outbound != "" {
+		for _, iface := range candidates {
+			for _, addr := range iface.Addrs(IPDual) {
+				if addr.IP.String() == outbound {
+					return iface, nil
+				}
+			}
+		}
+	}
+	for _, re := range include {
+		for _, iface := range candidates {
+			if re.MatchString(iface.Name) {
+				return iface, nil
+			}
+		}
+	}
+	return candidates[0], nil
+}
+
+// eligible 接口是否符合条件
+func (p PrimaryPolicy) eligible(iface *Interface, exclude []*regexp.Regexp) bool {
+	switch {
+	case iface.Loopback():
+		return false
+	case iface.Virtual && !p.AllowVirtual:
+		return false
+	case !iface.Up() && !p.AllowDown:
+		return false
+	case p.RequireIP && len(iface.Addrs(p.Family)) == 0:
+		return false
+	case p.RequireMAC && len(iface.HardwareAddr) == 0:
+		return false
+	}
+	for _, re := range exclude {
+		if re.MatchString(iface.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// compileAll 编译正则
+func compileAll(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// operStateByFlags 根据 Flags 判断状态
+func operStateByFlags(iface *Interface) string {
+	if iface.Up() {
+		return "up"
+	}
+	return "down"
+}
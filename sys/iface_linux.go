@@ -0,0 +1,48 @@
+package sys
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readLinkInfo 从 /sys/class/net 读取速率、状态和类型
+func readLinkInfo(iface *Interface) {
+	iface.Kind, iface.Virtual = kindByName(iface)
+	dir := filepath.Join("/sys/class/net", iface.Name)
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+	if !exists("") {
+		iface.OperState = operStateByFlags(iface)
+		return
+	}
+	iface.OperState = read("operstate")
+	if speed, err := strconv.Atoi(read("speed")); err == nil && speed > 0 {
+		iface.Speed = speed
+	}
+	switch {
+	case iface.Loopback():
+	case exists("bridge"):
+		iface.Kind, iface.Virtual = KindBridge, true
+	case exists("tun_flags"):
+		iface.Kind, iface.Virtual = KindTun, true
+	case exists("wireless"), exists("phy80211"):
+		iface.Kind = KindWireless
+	case !exists("device"):
+		// 没有对应的硬件设备
+		iface.Virtual = true
+		if iface.Kind == KindEthernet {
+			iface.Kind = KindVirtual
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package sys
+
+// readLinkInfo 根据名称判断类型，状态根据 Flags 判断
+func readLinkInfo(iface *Interface) {
+	iface.Kind, iface.Virtual = kindByName(iface)
+	iface.OperState = operStateByFlags(iface)
+}
@@ -0,0 +1,141 @@
+package sys
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/snowlyg/helper/i18n"
+)
+
+// testIface 测试接口
+func testIface(index int, name, mac string, flags net.Flags, ipv4 ...string) Interface {
+	iface := Interface{Index: index, Name: name, Flags: flags}
+	if mac != "" {
+		hw, err := net.ParseMAC(mac)
+		if err != nil {
+			panic(err)
+		}
+		iface.HardwareAddr = hw
+	}
+	for _, ip := range ipv4 {
+		iface.IPv4 = append(iface.IPv4, Addr{IP: net.ParseIP(ip).To4(), PrefixLen: 24})
+	}
+	iface.Kind, iface.Virtual = kindByName(&iface)
+	return iface
+}
+
+// testIfaces 测试接口列表
+func testIfaces() []Interface {
+	return []Interface{
+		testIface(1, "lo", "", net.FlagUp|net.FlagLoopback, "127.0.0.1"),
+		testIface(2, "docker0", "02:42:ac:11:00:01", net.FlagUp, "172.17.0.1"),
+		testIface(3, "wlan0", "00:16:3e:00:00:03", net.FlagUp, "192.168.1.3"),
+		testIface(4, "ens33", "00:16:3e:00:00:04", net.FlagUp, "10.0.0.4"),
+		testIface(5, "eth0", "00:16:3e:00:00:05", 0),
+		testIface(6, "enp3s0", "", net.FlagUp),
+	}
+}
+
+func TestKindByName(t *testing.T) {
+	t.Run("测试接口类型", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			flags   net.Flags
+			kind    string
+			virtual bool
+		}{
+			{"lo", net.FlagLoopback, KindLoopback, true},
+			{"eth0", 0, KindEthernet, false},
+			{"ens33", 0, KindEthernet, false},
+			{"wlan0", 0, KindWireless, false},
+			{"wlp2s0", 0, KindWireless, false},
+			{"WLAN", 0, KindWireless, false},
+			{"docker0", 0, KindDocker, true},
+			{"veth1a2b", 0, KindVeth, true},
+			{"br-0f3a", 0, KindBridge, true},
+			{"virbr0", 0, KindBridge, true},
+			{"tun0", 0, KindTun, true},
+			{"wg0", 0, KindTun, true},
+			{"vboxnet0", 0, KindVirtual, true},
+			{"cali123", 0, KindVirtual, true},
+		}
+		for _, c := range cases {
+			kind, virtual := kindByName(&Interface{Name: c.name, Flags: c.flags})
+			if kind != c.kind || virtual != c.virtual {
+				t.Errorf("kindByName(%s) = %s %v, want %s %v", c.name, kind, virtual, c.kind, c.virtual)
+			}
+		}
+	})
+}
+
+func TestSelectPrimary(t *testing.T) {
+	cases := []struct {
+		name     string
+		policy   PrimaryPolicy
+		outbound string
+		want     string
+	}{
+		{name: "默认规则按名称顺序", want: "ens33"},
+		{name: "允许未启用的接口", policy: PrimaryPolicy{AllowDown: true}, want: "eth0"},
+		{name: "优先外网地址所在接口", outbound: "192.168.1.3", want: "wlan0"},
+		{name: "外网地址不在候选接口", outbound: "172.17.0.1", want: "ens33"},
+		{name: "允许虚拟接口", policy: PrimaryPolicy{AllowVirtual: true, Names: []string{`^docker`}}, want: "docker0"},
+		{name: "排除接口", policy: PrimaryPolicy{Exclude: []string{`^ens`}}, want: "enp3s0"},
+		{name: "必须有地址", policy: PrimaryPolicy{RequireIP: true, Exclude: []string{`^ens`}}, want: "wlan0"},
+		{name: "必须有 MAC 地址", policy: PrimaryPolicy{RequireMAC: true, Exclude: []string{`^ens`}}, want: "wlan0"},
+		{name: "名称都不匹配时使用第一个", policy: PrimaryPolicy{Names: []string{`^xyz`}}, want: "wlan0"},
+	}
+	for _, c := range cases {
+		t.Run("测试"+c.name, func(t *testing.T) {
+			iface, err := SelectPrimary(testIfaces(), c.policy, c.outbound)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if iface.Name != c.want {
+				t.Errorf("SelectPrimary() want %s but get %s", c.want, iface.Name)
+			}
+		})
+	}
+	t.Run("测试没有符合条件的接口", func(t *testing.T) {
+		if _, err := SelectPrimary(testIfaces(), PrimaryPolicy{Exclude: []string{`.*`}}, ""); !errors.Is(err, ErrNoInterface) {
+			t.Errorf("SelectPrimary() want %v but get %v", ErrNoInterface, err)
+		}
+		if _, err := SelectPrimary(nil, PrimaryPolicy{}, ""); !errors.Is(err, ErrNoInterface) {
+			t.Errorf("SelectPrimary(nil) want %v but get %v", ErrNoInterface, err)
+		}
+	})
+	t.Run("测试正则错误", func(t *testing.T) {
+		if _, err := SelectPrimary(testIfaces(), PrimaryPolicy{Names: []string{`(`}}, ""); err == nil {
+			t.Error("SelectPrimary() want error but get nil")
+		}
+	})
+}
+
+func TestPrimaryMAC(t *testing.T) {
+	defer func(f func() ([]Interface, error)) { listInterfaces = f }(listInterfaces)
+	t.Run("测试主接口 MAC 地址", func(t *testing.T) {
+		listInterfaces = func() ([]Interface, error) { return testIfaces(), nil }
+		if got := PrimaryMAC(PrimaryPolicy{}); got != "00:16:3e:00:00:04" {
+			t.Errorf("PrimaryMAC() get %s", got)
+		}
+		// enp3s0 没有 MAC 地址，跳过
+		if got := PrimaryMAC(PrimaryPolicy{Names: []string{`^enp`}, Exclude: []string{`^ens`}}); got != "00:16:3e:00:00:03" {
+			t.Errorf("PrimaryMAC() get %s", got)
+		}
+	})
+	t.Run("测试没有接口", func(t *testing.T) {
+		listInterfaces = func() ([]Interface, error) { return nil, nil }
+		if got := PrimaryMAC(PrimaryPolicy{}); got != "" {
+			t.Errorf("PrimaryMAC() want empty but get %s", got)
+		}
+		if _, err := PrimaryInterface(PrimaryPolicy{}); !errors.Is(err, ErrNoInterface) {
+			t.Errorf("PrimaryInterface() want %v but get %v", ErrNoInterface, err)
+		}
+	})
+	t.Run("测试错误信息翻译", func(t *testing.T) {
+		if got := i18n.Localize(ErrNoInterface, i18n.EnUS); got != "no matching network interface" {
+			t.Errorf("Localize(en-US) get %s", got)
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package sys
+
+import (
+	"github.com/snowlyg/helper/i18n"
+)
+
+// 系统信息错误信息，使用 i18n.SetLang 切换语言
+func init() {
+	i18n.Register(i18n.ZhCN, map[string]string{
+		"sys.no_interface": "没有符合条件的网络接口",
+		"sys.no_component": "没有可用于生成设备ID的硬件信息",
+	})
+	i18n.Register(i18n.EnUS, map[string]string{
+		"sys.no_interface": "no matching network interface",
+		"sys.no_component": "no hardware information available for the device ID",
+	})
+}
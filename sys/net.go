@@ -3,12 +3,84 @@ package sys
 import (
 	"fmt"
 	"net"
-	"strconv"
 	"strings"
+
+	"github.com/snowlyg/helper/ipx"
+)
+
+// IPFamily IP 地址类型
+type IPFamily int
+
+const (
+	IPv4   IPFamily = iota //只使用 IPv4
+	IPv6                   //只使用 IPv6
+	IPDual                 //IPv4 和 IPv6,IPv4 优先
 )
 
+func (f IPFamily) String() string {
+	switch f {
+	case IPv6:
+		return "ipv6"
+	case IPDual:
+		return "dual"
+	default:
+		return "ipv4"
+	}
+}
+
+// Match 地址是否符合类型
+func (f IPFamily) Match(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	switch f {
+	case IPv6:
+		return ip.To4() == nil
+	case IPDual:
+		return true
+	default:
+		return ip.To4() != nil
+	}
+}
+
+// Network 对应的网络类型，例如 ip4,udp4
+// @param network ip,tcp,udp
+func (f IPFamily) Network(network string) string {
+	switch f {
+	case IPv4:
+		return network + "4"
+	case IPv6:
+		return network + "6"
+	default:
+		return network
+	}
+}
+
+// SortIPs 按类型过滤地址，IPDual 时 IPv4 在前，同类地址保持原来的顺序
+func SortIPs(ips []net.IP, family IPFamily) []net.IP {
+	var v4, v6 []net.IP
+	for _, ip := range ips {
+		if !family.Match(ip) {
+			continue
+		}
+		if ip.To4() != nil {
+			v4 = append(v4, ip)
+		} else {
+			v6 = append(v6, ip)
+		}
+	}
+	return append(v4, v6...)
+}
+
 func IntranetIP() (ips []string, err error) {
+	return IntranetIPs(IPv4)
+}
+
+// IntranetIPs 内网地址，IPv6 为 fc00::/7 唯一本地地址，不包括链路本地地址
+// @param family 地址类型
+func IntranetIPs(family IPFamily) (ips []string, err error) {
 	ips = make([]string, 0)
+	var all []net.IP
 
 	ifaces, e := net.Interfaces()
 	if e != nil {
@@ -43,57 +115,26 @@ func IntranetIP() (ips []string, err error) {
 				ip = v.IP
 			}
 
-			if ip == nil || ip.IsLoopback() {
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
 				continue
 			}
 
-			ip = ip.To4()
-			if ip == nil {
-				continue // not an ipv4 address
-			}
-
-			ipStr := ip.String()
-			if IsIntranet(ipStr) {
-				ips = append(ips, ipStr)
+			if IsIntranet(ip.String()) {
+				all = append(all, ip)
 			}
 		}
 	}
 
+	for _, ip := range SortIPs(all, family) {
+		ips = append(ips, ip.String())
+	}
 	return ips, nil
 }
 
+// IsIntranet 是否是内网地址，包括 RFC1918 私有地址、100.64.0.0/10 共享地址和 IPv6 唯一本地地址 fc00::/7
 func IsIntranet(ipStr string) bool {
-	if strings.HasPrefix(ipStr, "10.") {
-		return true
-	}
-
-	// for didi
-	if strings.HasPrefix(ipStr, "100.") {
-		return true
-	}
-
-	if strings.HasPrefix(ipStr, "192.168.") {
-		return true
-	}
-
-	if strings.HasPrefix(ipStr, "172.") {
-		// 172.16.0.0-172.31.255.255
-		arr := strings.Split(ipStr, ".")
-		if len(arr) != 4 {
-			return false
-		}
-
-		second, err := strconv.ParseInt(arr[1], 10, 64)
-		if err != nil {
-			return false
-		}
-
-		if second >= 16 && second <= 31 {
-			return true
-		}
-	}
-
-	return false
+	addr, err := ipx.ParseAddr(ipStr)
+	return err == nil && ipx.IsIntranet(addr)
 }
 
 // ${sn}-${hostname}-${ip}
@@ -118,7 +159,23 @@ func LocalHostIdent() string {
 }
 
 func GetOutboundIpaddr() string {
-	conn, err := net.Dial("udp4", "1.2.3.4:56")
+	return OutboundIP(IPv4)
+}
+
+// OutboundIP 访问外网使用的本机地址，只查询路由不会发送数据
+// @param family 地址类型,IPDual 时先查询 IPv4
+func OutboundIP(family IPFamily) string {
+	if family == IPDual {
+		if ip := OutboundIP(IPv4); ip != "" {
+			return ip
+		}
+		return OutboundIP(IPv6)
+	}
+	remote := "1.2.3.4:56"
+	if family == IPv6 {
+		remote = "[2001:db8::1]:56"
+	}
+	conn, err := net.Dial(family.Network("udp"), remote)
 	if err != nil {
 		return ""
 	}